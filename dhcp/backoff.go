@@ -0,0 +1,21 @@
+package dhcp
+
+import "time"
+
+const (
+	backoffInitial = 2 * time.Second
+	backoffMax     = 64 * time.Second
+)
+
+// backoff returns the retransmission delay for the n'th retry (n starts
+// at 0), doubling from backoffInitial up to backoffMax.
+func backoff(n int) time.Duration {
+	d := backoffInitial
+	for i := 0; i < n; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+	return d
+}
@@ -0,0 +1,502 @@
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// State is a client state as defined by the state diagram in RFC 2131
+// section 4.4.
+type State int
+
+// Client states. The INIT-REBOOT/REBOOTING states are not implemented;
+// a Client always starts a lease from INIT.
+const (
+	StateInit State = iota
+	StateSelecting
+	StateRequesting
+	StateBound
+	StateRenewing
+	StateRebinding
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInit:
+		return "INIT"
+	case StateSelecting:
+		return "SELECTING"
+	case StateRequesting:
+		return "REQUESTING"
+	case StateBound:
+		return "BOUND"
+	case StateRenewing:
+		return "RENEWING"
+	case StateRebinding:
+		return "REBINDING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Config is the network configuration handed out by the server in an
+// ACK, decoded from its options.
+type Config struct {
+	ClientIP   net.IP
+	ServerIP   net.IP
+	Router     net.IP
+	SubnetMask net.IP
+	DNS        []net.IP
+	LeaseTime  time.Duration
+	T1         time.Duration
+	T2         time.Duration
+}
+
+// LeaseCallback is invoked whenever the client's address changes: on the
+// initial bind (oldAddr is nil), on renewal/rebinding if the server
+// hands out a different address, on release (newAddr is nil), and on
+// losing the lease entirely after a NAK or a failed REBINDING (newAddr
+// and cfg both nil).
+type LeaseCallback func(oldAddr, newAddr net.IP, cfg *Config)
+
+// NAKError reports that server sent a DHCPNAK in response to a REQUEST.
+type NAKError struct {
+	Server string
+}
+
+func (e *NAKError) Error() string {
+	return fmt.Sprintf("dhcp: server %s sent NAK", e.Server)
+}
+
+// Client implements the RFC 2131 client state machine on a single
+// interface: INIT -> SELECTING -> REQUESTING -> BOUND, with automatic
+// RENEWING/REBINDING driven by the T1/T2 timers in the lease.
+type Client struct {
+	Iface string
+	MAC   string
+
+	mu      sync.Mutex
+	state   State
+	conn    *net.UDPConn
+	cfg     Config
+	onLease LeaseCallback
+
+	cancelRenew context.CancelFunc
+}
+
+// NewClient returns a Client bound to the given interface and hardware
+// address. Call Acquire to obtain a lease.
+func NewClient(iface, mac string) *Client {
+	return &Client{Iface: iface, MAC: mac, state: StateInit}
+}
+
+// OnLease registers a callback invoked on every address change.
+func (c *Client) OnLease(cb LeaseCallback) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onLease = cb
+}
+
+// State returns the client's current state.
+func (c *Client) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Config returns the most recently acquired lease configuration.
+func (c *Client) Config() Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg
+}
+
+// Acquire runs INIT -> SELECTING -> REQUESTING -> BOUND: it broadcasts
+// DISCOVER (retrying with exponential backoff until ctx is done), picks
+// the first OFFER received, requests it, and waits for the ACK. Once
+// bound, it schedules the T1/T2 renewal timers in the background.
+func (c *Client) Acquire(ctx context.Context) error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: 68})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	if err := c.reacquire(ctx, conn, nil); err != nil {
+		conn.Close()
+		return err
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.cancelRenew = cancel
+	c.mu.Unlock()
+	go c.runTimers(renewCtx, conn)
+
+	return nil
+}
+
+// reacquire runs SELECTING -> REQUESTING -> BOUND from scratch on conn,
+// binding the result (and invoking the lease callback, since oldAddr is
+// what the caller last had, or nil for a first-time Acquire) on
+// success.
+func (c *Client) reacquire(ctx context.Context, conn *net.UDPConn, oldAddr net.IP) error {
+	c.mu.Lock()
+	c.state = StateSelecting
+	c.mu.Unlock()
+
+	offer, err := c.discover(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.state = StateRequesting
+	c.mu.Unlock()
+
+	ack, err := c.request(ctx, conn, offer, offerServerIP(offer).String(), false, StateRequesting)
+	if err != nil {
+		return err
+	}
+
+	c.bindFrom(ack, oldAddr)
+	return nil
+}
+
+// offerServerIP returns the server address to send the REQUEST to:
+// option 54 (Server Identifier) if present, falling back to Siaddr.
+func offerServerIP(offer *Packet) net.IP {
+	if data, ok := offer.GetOption(ServerIdentifier); ok && len(data) == 4 {
+		return net.IP(data)
+	}
+	return net.IP(offer.Siaddr[:])
+}
+
+// discover broadcasts DISCOVER, retrying with exponential backoff until
+// an OFFER arrives or ctx is done.
+func (c *Client) discover(ctx context.Context, conn *net.UDPConn) (*Packet, error) {
+	p := NewDiscoverPacket()
+	if err := p.ParseMAC(c.MAC); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := p.SendFrom(conn); err != nil {
+			return nil, err
+		}
+
+		var o Packet
+		_, err := o.Receive(conn, backoff(attempt))
+		if err == nil && o.MessageType() == DHCPOffer && o.Xid == p.Xid {
+			return &o, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+}
+
+// request sends REQUEST and waits for ACK/NAK, retrying with exponential
+// backoff. unicast selects whether the request is sent directly to the
+// server, as RFC 2131 section 4.4.5 requires for renewals. reqState is
+// the state this REQUEST is sent from and, per RFC 2131 table 4, governs
+// which options are legal: Requested IP Address (50) and Server
+// Identifier (54) are only filled in for the initial SELECTING ->
+// REQUESTING transition, and MUST NOT appear for RENEWING/REBINDING.
+func (c *Client) request(ctx context.Context, conn *net.UDPConn, offer *Packet, server string, unicast bool, reqState State) (*Packet, error) {
+	serverIP := net.ParseIP(server)
+
+	req := Packet{
+		Op:    1,
+		Htype: 1,
+		Hlen:  6,
+		Xid:   offer.Xid,
+		Options: []byte{
+			DHCPMessageType, 1, DHCPRequest,
+			EndOption,
+		},
+	}
+	if err := req.ParseMAC(c.MAC); err != nil {
+		return nil, err
+	}
+	if unicast {
+		req.Ciaddr = offer.Ciaddr
+	}
+	if reqState == StateRequesting {
+		req.AddOption(RequestedIPAddress, offer.Yiaddr[:])
+		if serverIP4 := serverIP.To4(); serverIP4 != nil {
+			req.AddOption(ServerIdentifier, serverIP4)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var err error
+		if unicast {
+			err = req.SendTo(conn, serverIP)
+		} else {
+			err = req.SendFrom(conn)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var reply Packet
+		_, err = reply.Receive(conn, backoff(attempt))
+		if err == nil && reply.Xid == req.Xid {
+			switch reply.MessageType() {
+			case DHCPAck:
+				return &reply, nil
+			case DHCPNack:
+				return nil, &NAKError{Server: server}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+}
+
+// runTimers waits out T1 and T2, triggering RENEWING and REBINDING. Per
+// RFC 2131 section 4.4.5, a DHCPNAK at any point sends the client back
+// to INIT to restart discovery from scratch, as does a REBINDING that
+// times out with no reply. Either way the lease is gone before
+// discovery succeeds again, so onLease fires with newAddr nil in the
+// meantime.
+func (c *Client) runTimers(ctx context.Context, conn *net.UDPConn) {
+	for {
+		cfg := c.Config()
+
+		t1 := time.NewTimer(cfg.T1)
+		select {
+		case <-ctx.Done():
+			t1.Stop()
+			return
+		case <-t1.C:
+		}
+
+		c.mu.Lock()
+		c.state = StateRenewing
+		c.mu.Unlock()
+
+		t2At := time.Now().Add(cfg.T2 - cfg.T1)
+		renewCtx, cancelRenew := context.WithDeadline(ctx, t2At)
+		ack, err := c.request(renewCtx, conn, &Packet{Xid: newXid(), Yiaddr: cfg.ClientIPAsOption(), Ciaddr: cfg.ClientIPAsOption(), Chaddr: [16]byte{}}, cfg.ServerIP.String(), true, StateRenewing)
+		cancelRenew()
+		if err == nil {
+			c.bindFrom(ack, cfg.ClientIP)
+			continue
+		}
+		if isNAK(err) {
+			if !c.restart(ctx, conn, cfg.ClientIP) {
+				return
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		c.state = StateRebinding
+		c.mu.Unlock()
+
+		t2 := time.NewTimer(time.Until(t2At))
+		select {
+		case <-ctx.Done():
+			t2.Stop()
+			return
+		case <-t2.C:
+		}
+
+		rebindCtx, cancelRebind := context.WithTimeout(ctx, cfg.LeaseTime-cfg.T2)
+		ack, err = c.request(rebindCtx, conn, &Packet{Xid: newXid(), Yiaddr: cfg.ClientIPAsOption(), Ciaddr: cfg.ClientIPAsOption(), Chaddr: [16]byte{}}, "255.255.255.255", false, StateRebinding)
+		cancelRebind()
+		if err == nil {
+			c.bindFrom(ack, cfg.ClientIP)
+			continue
+		}
+		if !c.restart(ctx, conn, cfg.ClientIP) {
+			return
+		}
+	}
+}
+
+// restart handles losing the lease (a NAK, or a REBINDING that never
+// got a reply): it notifies onLease that the address is gone, then
+// restarts discovery from INIT. It reports whether discovery succeeded
+// before ctx was done.
+func (c *Client) restart(ctx context.Context, conn *net.UDPConn, oldAddr net.IP) bool {
+	c.mu.Lock()
+	c.state = StateInit
+	c.cfg = Config{}
+	cb := c.onLease
+	c.mu.Unlock()
+
+	if cb != nil {
+		cb(oldAddr, nil, nil)
+	}
+
+	return c.reacquire(ctx, conn, nil) == nil
+}
+
+// isNAK reports whether err is (or wraps) a NAKError.
+func isNAK(err error) bool {
+	var nak *NAKError
+	return errors.As(err, &nak)
+}
+
+func (c *Client) bindFrom(ack *Packet, oldAddr net.IP) {
+	cfg := decodeConfig(ack)
+	c.mu.Lock()
+	c.state = StateBound
+	c.cfg = cfg
+	cb := c.onLease
+	c.mu.Unlock()
+
+	if cb != nil && !cfg.ClientIP.Equal(oldAddr) {
+		cb(oldAddr, cfg.ClientIP, &cfg)
+	}
+}
+
+// Renew forces an immediate RENEWING-style unicast REQUEST, bypassing
+// the T1 timer.
+func (c *Client) Renew() error {
+	c.mu.Lock()
+	conn := c.conn
+	cfg := c.cfg
+	c.state = StateRenewing
+	c.mu.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("dhcp: client has no active lease")
+	}
+
+	ack, err := c.request(context.Background(), conn, &Packet{
+		Xid:    newXid(),
+		Ciaddr: cfg.ClientIPAsOption(),
+		Yiaddr: cfg.ClientIPAsOption(),
+	}, cfg.ServerIP.String(), true, StateRenewing)
+	if err != nil {
+		return err
+	}
+
+	c.bindFrom(ack, cfg.ClientIP)
+	return nil
+}
+
+// Release sends DHCPRELEASE for the current lease and returns the
+// client to the INIT state.
+func (c *Client) Release() error {
+	c.mu.Lock()
+	conn := c.conn
+	cfg := c.cfg
+	cancel := c.cancelRenew
+	c.mu.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("dhcp: client has no active lease")
+	}
+	if cancel != nil {
+		cancel()
+	}
+
+	rel := Packet{
+		Op:     1,
+		Htype:  1,
+		Hlen:   6,
+		Xid:    newXid(),
+		Ciaddr: cfg.ClientIPAsOption(),
+		Options: []byte{
+			DHCPMessageType, 1, DHCPRelease,
+			EndOption,
+		},
+	}
+	rel.AddOption(ServerIdentifier, cfg.ServerIP.To4())
+	if err := rel.SendTo(conn, cfg.ServerIP); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	oldAddr := c.cfg.ClientIP
+	c.state = StateInit
+	c.cfg = Config{}
+	cb := c.onLease
+	c.mu.Unlock()
+
+	if cb != nil {
+		cb(oldAddr, nil, nil)
+	}
+	return conn.Close()
+}
+
+// ClientIPAsOption converts cfg.ClientIP to the fixed-size type used by
+// Packet's Ciaddr/Yiaddr fields.
+func (cfg Config) ClientIPAsOption() IPv4Address {
+	var ip IPv4Address
+	copy(ip[:], cfg.ClientIP.To4())
+	return ip
+}
+
+// decodeConfig builds a Config from an ACK packet's fields and options,
+// deriving T1/T2 per RFC 2131 section 4.4.5: use options 58/59 when
+// present and consistent with the lease, otherwise default to 50% and
+// 85% of the lease time.
+func decodeConfig(ack *Packet) Config {
+	cfg := Config{
+		ClientIP: net.IP(ack.Yiaddr[:]),
+		ServerIP: net.IP(ack.Siaddr[:]),
+	}
+
+	if data, ok := ack.GetOption(ServerIdentifier); ok && len(data) == 4 {
+		cfg.ServerIP = net.IP(data)
+	}
+	if data, ok := ack.GetOption(Router); ok && len(data) >= 4 {
+		cfg.Router = net.IP(data[0:4])
+	}
+	if data, ok := ack.GetOption(SubnetMask); ok && len(data) == 4 {
+		cfg.SubnetMask = net.IP(data)
+	}
+	if data, ok := ack.GetOption(DomainNameServer); ok {
+		for i := 0; i+4 <= len(data); i += 4 {
+			cfg.DNS = append(cfg.DNS, net.IP(data[i:i+4]))
+		}
+	}
+
+	lease := 0 * time.Second
+	if data, ok := ack.GetOption(IPAddressLeaseTime); ok && len(data) == 4 {
+		lease = time.Duration(binary.BigEndian.Uint32(data)) * time.Second
+	}
+	cfg.LeaseTime = lease
+
+	t1, t1ok := readSeconds(ack, RenewalTimeValue)
+	t2, t2ok := readSeconds(ack, RebindingTimeValue)
+
+	if !t1ok || t1 <= 0 || t1 >= lease {
+		t1 = (lease * 5) / 10
+	}
+	if !t2ok || t2 <= t1 || t2 >= lease {
+		t2 = (lease * 85) / 100
+	}
+	cfg.T1 = t1
+	cfg.T2 = t2
+
+	return cfg
+}
+
+func readSeconds(p *Packet, code byte) (time.Duration, bool) {
+	data, ok := p.GetOption(code)
+	if !ok || len(data) != 4 {
+		return 0, false
+	}
+	return time.Duration(binary.BigEndian.Uint32(data)) * time.Second, true
+}
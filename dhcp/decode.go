@@ -0,0 +1,180 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+// DecodedOption is an option with no dedicated field on DecodedPacket:
+// its raw bytes are kept under Raw so callers can still inspect it.
+type DecodedOption struct {
+	Code byte   `json:"code"`
+	Name string `json:"name,omitempty"`
+	Raw  []byte `json:"raw"`
+}
+
+// DecodedPacket is a Packet with its fixed fields and well-known
+// options converted to typed Go values, suitable for serialization or
+// library use without going through a text pretty-printer.
+type DecodedPacket struct {
+	ClientIP net.IP `json:"client_ip,omitempty"`
+	YourIP   net.IP `json:"your_ip,omitempty"`
+	ServerIP net.IP `json:"server_ip,omitempty"`
+	RelayIP  net.IP `json:"relay_ip,omitempty"`
+
+	MessageType string `json:"message_type,omitempty"`
+
+	Routers           []net.IP        `json:"routers,omitempty"`
+	DomainNameServer  []net.IP        `json:"domain_name_server,omitempty"`
+	NetBIOSNameServer []net.IP        `json:"netbios_name_server,omitempty"`
+	SubnetMask        net.IP          `json:"subnet_mask,omitempty"`
+	BroadcastAddress  net.IP          `json:"broadcast_address,omitempty"`
+	ServerIdentifier  net.IP          `json:"server_identifier,omitempty"`
+	LeaseTime         time.Duration   `json:"lease_time,omitempty"`
+	RenewalTime       time.Duration   `json:"renewal_time,omitempty"`
+	RebindingTime     time.Duration   `json:"rebinding_time,omitempty"`
+	HostName          string          `json:"host_name,omitempty"`
+	DomainName        string          `json:"domain_name,omitempty"`
+	DomainSearch      []string        `json:"domain_search,omitempty"`
+	RelayAgentInfo    *RelayAgentInfo `json:"relay_agent_info,omitempty"`
+
+	// Options holds every option without a dedicated field above, in
+	// packet order, so nothing is silently dropped.
+	Options []DecodedOption `json:"options,omitempty"`
+}
+
+// Decode converts a Packet into a DecodedPacket, decoding every
+// well-known option into a typed field and keeping everything else as
+// a raw DecodedOption.
+func Decode(p *Packet) (*DecodedPacket, error) {
+	dp := &DecodedPacket{
+		ClientIP: net.IP(p.Ciaddr[:]),
+		YourIP:   net.IP(p.Yiaddr[:]),
+		ServerIP: net.IP(p.Siaddr[:]),
+		RelayIP:  net.IP(p.Giaddr[:]),
+	}
+
+	opts := p.Options
+	for i := 0; i < len(opts); {
+		o := opts[i]
+		if o == EndOption {
+			break
+		}
+		if o == PadOption {
+			i++
+			continue
+		}
+		if i+2 > len(opts) {
+			break
+		}
+
+		length := int(opts[i+1])
+		if i+2+length > len(opts) {
+			break
+		}
+		data := opts[i+2 : i+2+length]
+
+		switch o {
+		case DHCPMessageType:
+			if len(data) == 1 {
+				dp.MessageType = messageTypeName(data[0])
+			}
+		case Router:
+			dp.Routers = append(dp.Routers, ipList(data)...)
+		case DomainNameServer:
+			dp.DomainNameServer = append(dp.DomainNameServer, ipList(data)...)
+		case NetBIOSNameServer:
+			dp.NetBIOSNameServer = append(dp.NetBIOSNameServer, ipList(data)...)
+		case SubnetMask:
+			dp.SubnetMask = ipOf(data)
+		case BroadcastAddress:
+			dp.BroadcastAddress = ipOf(data)
+		case ServerIdentifier:
+			dp.ServerIdentifier = ipOf(data)
+		case IPAddressLeaseTime:
+			dp.LeaseTime = seconds(data)
+		case RenewalTimeValue:
+			dp.RenewalTime = seconds(data)
+		case RebindingTimeValue:
+			dp.RebindingTime = seconds(data)
+		case HostName:
+			dp.HostName = string(data)
+		case DomainName:
+			dp.DomainName = string(data)
+		case DomainSearch:
+			// Handled after the loop: RFC 3397 concatenates all
+			// instances before decompression.
+		case RelayAgentInformation:
+			info, err := DecodeRelayAgentInfo(data)
+			if err != nil {
+				return nil, err
+			}
+			dp.RelayAgentInfo = info
+		default:
+			dp.Options = append(dp.Options, DecodedOption{
+				Code: o,
+				Raw:  append([]byte(nil), data...),
+			})
+		}
+
+		i += 2 + length
+	}
+
+	var domainData []byte
+	for _, d := range p.GetOptions(DomainSearch) {
+		domainData = append(domainData, d...)
+	}
+	if domainData != nil {
+		names, err := DecodeDomainSearch(domainData)
+		if err != nil {
+			return nil, err
+		}
+		dp.DomainSearch = names
+	}
+
+	return dp, nil
+}
+
+func ipOf(data []byte) net.IP {
+	if len(data) != 4 {
+		return nil
+	}
+	return net.IP(data)
+}
+
+func ipList(data []byte) []net.IP {
+	var ips []net.IP
+	for i := 0; i+4 <= len(data); i += 4 {
+		ips = append(ips, net.IP(data[i:i+4]))
+	}
+	return ips
+}
+
+func seconds(data []byte) time.Duration {
+	if len(data) != 4 {
+		return 0
+	}
+	return time.Duration(binary.BigEndian.Uint32(data)) * time.Second
+}
+
+func messageTypeName(t byte) string {
+	switch t {
+	case DHCPDiscover:
+		return "DHCPDISCOVER"
+	case DHCPOffer:
+		return "DHCPOFFER"
+	case DHCPRequest:
+		return "DHCPREQUEST"
+	case DHCPDecline:
+		return "DHCPDECLINE"
+	case DHCPAck:
+		return "DHCPACK"
+	case DHCPNack:
+		return "DHCPNACK"
+	case DHCPRelease:
+		return "DHCPRELEASE"
+	default:
+		return ""
+	}
+}
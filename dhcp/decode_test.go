@@ -0,0 +1,37 @@
+package dhcp
+
+import "testing"
+
+// TestDecodeConcatenatesSplitDomainSearch verifies that Decode collects
+// every option-119 occurrence and concatenates them before decompressing,
+// as RFC 3397 requires when a Domain Search value spans more than one
+// 255-byte option instance.
+func TestDecodeConcatenatesSplitDomainSearch(t *testing.T) {
+	data := rfc3397Example()
+
+	p := &Packet{
+		Options: []byte{
+			DHCPMessageType, 1, DHCPOffer,
+			EndOption,
+		},
+	}
+	// Split the encoded names across two option-119 instances, as a
+	// server would when the value exceeds 255 bytes.
+	p.AddOption(DomainSearch, data[:10])
+	p.AddOption(DomainSearch, data[10:])
+
+	dp, err := Decode(p)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := []string{"eng.example.com", "example.com"}
+	if len(dp.DomainSearch) != len(want) {
+		t.Fatalf("got %v, want %v", dp.DomainSearch, want)
+	}
+	for i := range want {
+		if dp.DomainSearch[i] != want[i] {
+			t.Fatalf("got %v, want %v", dp.DomainSearch, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package dhcp
+
+import "fmt"
+
+// maxDecompressionDepth bounds the number of compression pointers
+// followed while decoding a single name, guarding against pointer
+// loops.
+const maxDecompressionDepth = 128
+
+// DecodeDomainSearch decodes the Domain Search option (119, RFC 3397),
+// a sequence of RFC 1035 section 4.1.4 compressed domain names. Per
+// RFC 3397, data is the concatenation of every instance of option 119
+// in the packet, since pointers may refer across instances.
+func DecodeDomainSearch(data []byte) ([]string, error) {
+	var names []string
+
+	pos := 0
+	for pos < len(data) {
+		if data[pos] == 0 {
+			// A lone terminator between names; nothing to decode.
+			pos++
+			continue
+		}
+
+		name, next, err := decodeName(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		pos = next
+	}
+
+	return names, nil
+}
+
+// decodeName decodes a single compressed name starting at pos,
+// returning the name and the offset just past it in the caller's
+// stream: past its terminating zero label, or, if the name contains a
+// compression pointer, past the first pointer (never a position inside
+// the data a pointer leads to).
+func decodeName(data []byte, pos int) (string, int, error) {
+	var labels []string
+	depth := 0
+	end := -1 // first position past the name in the caller's stream
+
+	for {
+		if pos >= len(data) {
+			return "", 0, fmt.Errorf("dhcp: domain search: truncated name")
+		}
+
+		length := int(data[pos])
+
+		switch {
+		case length == 0:
+			if end == -1 {
+				end = pos + 1
+			}
+			name := joinLabels(labels)
+			if len(name) > 255 {
+				return "", 0, fmt.Errorf("dhcp: domain search: name exceeds 255 bytes")
+			}
+			return name, end, nil
+
+		case length&0xc0 == 0xc0:
+			if pos+1 >= len(data) {
+				return "", 0, fmt.Errorf("dhcp: domain search: truncated pointer")
+			}
+			offset := int(length&0x3f)<<8 | int(data[pos+1])
+			if offset >= pos {
+				return "", 0, fmt.Errorf("dhcp: domain search: pointer does not point backward")
+			}
+			if end == -1 {
+				end = pos + 2
+			}
+
+			depth++
+			if depth > maxDecompressionDepth {
+				return "", 0, fmt.Errorf("dhcp: domain search: compression pointer loop")
+			}
+			pos = offset
+
+		case length&0xc0 != 0:
+			return "", 0, fmt.Errorf("dhcp: domain search: reserved label length bits")
+
+		default:
+			if length > 63 {
+				return "", 0, fmt.Errorf("dhcp: domain search: label exceeds 63 bytes")
+			}
+			if pos+1+length > len(data) {
+				return "", 0, fmt.Errorf("dhcp: domain search: truncated label")
+			}
+			labels = append(labels, string(data[pos+1:pos+1+length]))
+			pos += 1 + length
+		}
+	}
+}
+
+func joinLabels(labels []string) string {
+	name := ""
+	for i, l := range labels {
+		if i > 0 {
+			name += "."
+		}
+		name += l
+	}
+	return name
+}
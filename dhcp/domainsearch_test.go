@@ -0,0 +1,63 @@
+package dhcp
+
+import "testing"
+
+// rfc3397Example builds the two-name example from RFC 3397 section 4.1:
+// "eng.example.com" followed by "example.com" via a pointer back into
+// the first name's "example.com" suffix.
+func rfc3397Example() []byte {
+	return []byte{
+		3, 'e', 'n', 'g',
+		7, 'e', 'x', 'a', 'm', 'p', 'l', 'e',
+		3, 'c', 'o', 'm',
+		0,
+		0xc0, 4, // pointer to offset 4
+	}
+}
+
+func TestDecodeDomainSearchPointerCompression(t *testing.T) {
+	names, err := DecodeDomainSearch(rfc3397Example())
+	if err != nil {
+		t.Fatalf("DecodeDomainSearch: %v", err)
+	}
+	want := []string{"eng.example.com", "example.com"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got %v, want %v", names, want)
+		}
+	}
+}
+
+func TestDecodeDomainSearchRejectsSelfPointer(t *testing.T) {
+	// A pointer must strictly point backward; one pointing at itself
+	// (or forward) could otherwise loop forever.
+	data := []byte{0xc0, 0}
+	if _, err := DecodeDomainSearch(data); err == nil {
+		t.Fatal("expected an error for a self-referencing compression pointer")
+	}
+}
+
+func TestDecodeDomainSearchRejectsForwardPointer(t *testing.T) {
+	data := []byte{0xc0, 2, 0, 0}
+	if _, err := DecodeDomainSearch(data); err == nil {
+		t.Fatal("expected an error for a pointer that does not point backward")
+	}
+}
+
+func TestDecodeDomainSearchLabelTooLong(t *testing.T) {
+	label := make([]byte, 1+64)
+	label[0] = 64 // exceeds the 63-byte cap
+	if _, err := DecodeDomainSearch(label); err == nil {
+		t.Fatal("expected an error for a label exceeding 63 bytes")
+	}
+}
+
+func TestDecodeDomainSearchTruncated(t *testing.T) {
+	data := []byte{5, 'e', 'n', 'g'} // claims 5 bytes, only 3 follow
+	if _, err := DecodeDomainSearch(data); err == nil {
+		t.Fatal("expected an error for a truncated label")
+	}
+}
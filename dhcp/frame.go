@@ -0,0 +1,126 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// broadcastMAC is the Ethernet broadcast address, used as the
+// destination when a client has no IP address yet and must reach the
+// server via raw Ethernet frames.
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// clientPort and serverPort are the well-known BOOTP/DHCP UDP ports.
+const (
+	clientPort = 68
+	serverPort = 67
+)
+
+// buildBroadcastFrame assembles a full Ethernet+IPv4+UDP frame carrying
+// payload (a marshaled Packet) from srcMAC, broadcast to
+// 255.255.255.255:67 from 0.0.0.0:68, for use on an interface with no
+// assigned IPv4 address.
+func buildBroadcastFrame(srcMAC net.HardwareAddr, payload []byte) []byte {
+	udp := buildUDP(net.IPv4zero, clientPort, net.IPv4bcast, serverPort, payload)
+	ip := buildIPv4(net.IPv4zero, net.IPv4bcast, udp)
+	return buildEthernet(srcMAC, broadcastMAC, 0x0800, ip)
+}
+
+func buildEthernet(src, dst net.HardwareAddr, ethType uint16, payload []byte) []byte {
+	frame := make([]byte, 14+len(payload))
+	copy(frame[0:6], dst)
+	copy(frame[6:12], src)
+	binary.BigEndian.PutUint16(frame[12:14], ethType)
+	copy(frame[14:], payload)
+	return frame
+}
+
+func buildIPv4(src, dst net.IP, payload []byte) []byte {
+	hdr := make([]byte, 20)
+	hdr[0] = 0x45 // version 4, IHL 5
+	hdr[1] = 0    // DSCP/ECN
+	binary.BigEndian.PutUint16(hdr[2:4], uint16(20+len(payload)))
+	binary.BigEndian.PutUint16(hdr[4:6], 0) // identification
+	binary.BigEndian.PutUint16(hdr[6:8], 0) // flags/fragment offset
+	hdr[8] = 64                             // TTL
+	hdr[9] = 17                             // protocol: UDP
+	binary.BigEndian.PutUint16(hdr[10:12], 0)
+	copy(hdr[12:16], src.To4())
+	copy(hdr[16:20], dst.To4())
+	binary.BigEndian.PutUint16(hdr[10:12], checksum(hdr))
+
+	return append(hdr, payload...)
+}
+
+func buildUDP(srcIP net.IP, srcPort int, dstIP net.IP, dstPort int, payload []byte) []byte {
+	hdr := make([]byte, 8)
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(srcPort))
+	binary.BigEndian.PutUint16(hdr[2:4], uint16(dstPort))
+	binary.BigEndian.PutUint16(hdr[4:6], uint16(8+len(payload)))
+	binary.BigEndian.PutUint16(hdr[6:8], 0) // checksum, filled below
+
+	packet := append(hdr, payload...)
+
+	pseudo := make([]byte, 12)
+	copy(pseudo[0:4], srcIP.To4())
+	copy(pseudo[4:8], dstIP.To4())
+	pseudo[9] = 17 // protocol: UDP
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(packet)))
+
+	sum := checksumAccumulate(0, pseudo)
+	sum = checksumAccumulate(sum, packet)
+	binary.BigEndian.PutUint16(packet[6:8], finishChecksum(sum))
+
+	return packet
+}
+
+// checksum computes the RFC 1071 Internet checksum of data.
+func checksum(data []byte) uint16 {
+	return finishChecksum(checksumAccumulate(0, data))
+}
+
+func checksumAccumulate(sum uint32, data []byte) uint32 {
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	return sum
+}
+
+func finishChecksum(sum uint32) uint16 {
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// parseBroadcastFrame strips the Ethernet/IPv4/UDP headers off a raw
+// frame received on the wire and returns the BOOTP/DHCP payload,
+// rejecting anything that isn't IPv4 UDP addressed to clientPort.
+func parseBroadcastFrame(frame []byte) ([]byte, error) {
+	if len(frame) < 14+20+8 {
+		return nil, fmt.Errorf("dhcp: raw frame too short (%d bytes)", len(frame))
+	}
+
+	ethType := binary.BigEndian.Uint16(frame[12:14])
+	if ethType != 0x0800 {
+		return nil, fmt.Errorf("dhcp: not an IPv4 frame (ethertype %#04x)", ethType)
+	}
+
+	ip := frame[14:]
+	ihl := int(ip[0]&0x0f) * 4
+	if ip[9] != 17 || len(ip) < ihl+8 {
+		return nil, fmt.Errorf("dhcp: not a UDP packet")
+	}
+
+	udp := ip[ihl:]
+	dstPort := binary.BigEndian.Uint16(udp[2:4])
+	if dstPort != clientPort {
+		return nil, fmt.Errorf("dhcp: not addressed to port %d", clientPort)
+	}
+
+	return udp[8:], nil
+}
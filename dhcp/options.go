@@ -0,0 +1,48 @@
+package dhcp
+
+// DHCP option codes, as assigned by RFC 2132 and friends.
+const (
+	PadOption             = 0
+	SubnetMask            = 1
+	TimeOffset            = 2
+	Router                = 3
+	TimeServer            = 4
+	NameServer            = 5
+	DomainNameServer      = 6
+	HostName              = 12
+	DomainName            = 15
+	BroadcastAddress      = 28
+	StaticRoute           = 33
+	VendorSpecific        = 43
+	NetBIOSNameServer     = 44
+	RequestedIPAddress    = 50
+	IPAddressLeaseTime    = 51
+	DHCPMessageType       = 53
+	ServerIdentifier      = 54
+	ParameterRequest      = 55
+	RenewalTimeValue      = 58
+	RebindingTimeValue    = 59
+	VendorClassID         = 60
+	ClientIdentifier      = 61
+	RelayAgentInformation = 82
+	DomainSearch          = 119
+	WebProxyServer        = 252
+	EndOption             = 255
+)
+
+// RFC 3046 option 82 (Relay Agent Information) sub-option codes.
+const (
+	CircuitID = 1
+	RemoteID  = 2
+)
+
+// DHCP message types, carried in the DHCPMessageType (53) option.
+const (
+	DHCPDiscover = 1
+	DHCPOffer    = 2
+	DHCPRequest  = 3
+	DHCPDecline  = 4
+	DHCPAck      = 5
+	DHCPNack     = 6
+	DHCPRelease  = 7
+)
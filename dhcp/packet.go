@@ -0,0 +1,287 @@
+// Package dhcp implements enough of RFC 2131/2132 to build, send and
+// parse BOOTP/DHCP packets.
+package dhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const magicCookie = 0x63825363
+
+// IPv4Address is a 4-byte IPv4 address as carried in a DHCP packet.
+type IPv4Address [4]byte
+
+func (ip IPv4Address) String() string {
+	return net.IP(ip[:]).String()
+}
+
+func (ip IPv4Address) isZero() bool {
+	return ip == IPv4Address{}
+}
+
+// Packet is a BOOTP/DHCP packet as defined by RFC 2131 section 2.
+type Packet struct {
+	Op      byte
+	Htype   byte
+	Hlen    byte
+	Hops    byte
+	Xid     uint32
+	Secs    uint16
+	Flags   uint16
+	Ciaddr  IPv4Address
+	Yiaddr  IPv4Address
+	Siaddr  IPv4Address
+	Giaddr  IPv4Address
+	Chaddr  [16]byte
+	Sname   [64]byte
+	File    [128]byte
+	Options []byte
+}
+
+// Marshal encodes the packet into its wire format, including the magic
+// cookie that precedes the options.
+func (p *Packet) Marshal() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(p.Op)
+	buf.WriteByte(p.Htype)
+	buf.WriteByte(p.Hlen)
+	buf.WriteByte(p.Hops)
+	binary.Write(buf, binary.BigEndian, p.Xid)
+	binary.Write(buf, binary.BigEndian, p.Secs)
+	binary.Write(buf, binary.BigEndian, p.Flags)
+	buf.Write(p.Ciaddr[:])
+	buf.Write(p.Yiaddr[:])
+	buf.Write(p.Siaddr[:])
+	buf.Write(p.Giaddr[:])
+	buf.Write(p.Chaddr[:])
+	buf.Write(p.Sname[:])
+	buf.Write(p.File[:])
+	binary.Write(buf, binary.BigEndian, uint32(magicCookie))
+	buf.Write(p.Options)
+	return buf.Bytes()
+}
+
+// Unmarshal decodes a wire-format BOOTP/DHCP packet into p.
+func (p *Packet) Unmarshal(data []byte) error {
+	if len(data) < 240 {
+		return fmt.Errorf("dhcp: packet too short (%d bytes)", len(data))
+	}
+
+	buf := bytes.NewReader(data)
+	binary.Read(buf, binary.BigEndian, &p.Op)
+	binary.Read(buf, binary.BigEndian, &p.Htype)
+	binary.Read(buf, binary.BigEndian, &p.Hlen)
+	binary.Read(buf, binary.BigEndian, &p.Hops)
+	binary.Read(buf, binary.BigEndian, &p.Xid)
+	binary.Read(buf, binary.BigEndian, &p.Secs)
+	binary.Read(buf, binary.BigEndian, &p.Flags)
+	buf.Read(p.Ciaddr[:])
+	buf.Read(p.Yiaddr[:])
+	buf.Read(p.Siaddr[:])
+	buf.Read(p.Giaddr[:])
+	buf.Read(p.Chaddr[:])
+	buf.Read(p.Sname[:])
+	buf.Read(p.File[:])
+
+	var cookie uint32
+	binary.Read(buf, binary.BigEndian, &cookie)
+	if cookie != magicCookie {
+		return fmt.Errorf("dhcp: bad magic cookie %x", cookie)
+	}
+
+	p.Options = make([]byte, buf.Len())
+	buf.Read(p.Options)
+	return nil
+}
+
+// GetOption returns the raw value of the first instance of option code
+// in p.Options, and whether it was present.
+func (p *Packet) GetOption(code byte) ([]byte, bool) {
+	opts := p.Options
+	for i := 0; i < len(opts); {
+		o := opts[i]
+		if o == EndOption {
+			break
+		}
+		if o == PadOption {
+			i++
+			continue
+		}
+		if i+2 > len(opts) {
+			return nil, false
+		}
+		length := int(opts[i+1])
+		if i+2+length > len(opts) {
+			return nil, false
+		}
+		if o == code {
+			return opts[i+2 : i+2+length], true
+		}
+		i += 2 + length
+	}
+	return nil, false
+}
+
+// GetOptions returns every instance of option code in p.Options, in
+// order, for options like Domain Search (RFC 3397) that split their
+// value across multiple 255-byte instances meant to be concatenated
+// before decoding.
+func (p *Packet) GetOptions(code byte) [][]byte {
+	var out [][]byte
+	opts := p.Options
+	for i := 0; i < len(opts); {
+		o := opts[i]
+		if o == EndOption {
+			break
+		}
+		if o == PadOption {
+			i++
+			continue
+		}
+		if i+2 > len(opts) {
+			break
+		}
+		length := int(opts[i+1])
+		if i+2+length > len(opts) {
+			break
+		}
+		if o == code {
+			out = append(out, opts[i+2:i+2+length])
+		}
+		i += 2 + length
+	}
+	return out
+}
+
+// AddOption appends option code/data to p.Options, moving a trailing End
+// option after it (or appending one if there was none) so the option
+// list stays well formed.
+func (p *Packet) AddOption(code byte, data []byte) {
+	opts := p.Options
+	if n := len(opts); n > 0 && opts[n-1] == EndOption {
+		opts = opts[:n-1]
+	}
+
+	opts = append(opts, code)
+	opts = append(opts, byte(len(data)))
+	opts = append(opts, data...)
+	opts = append(opts, EndOption)
+
+	p.Options = opts
+}
+
+// MessageType returns the value of the DHCPMessageType (53) option, or 0
+// if it is absent.
+func (p *Packet) MessageType() byte {
+	if data, ok := p.GetOption(DHCPMessageType); ok && len(data) == 1 {
+		return data[0]
+	}
+	return 0
+}
+
+// Send broadcasts the packet's wire format from UDP port 68 to the
+// DHCP server port (67) on the local broadcast address.
+func (p *Packet) Send() error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: 68})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return p.SendFrom(conn)
+}
+
+// SendFrom broadcasts the packet's wire format from an already open UDP
+// socket, so callers can reuse the socket used to listen for replies.
+func (p *Packet) SendFrom(conn *net.UDPConn) error {
+	dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 67}
+	_, err := conn.WriteToUDP(p.Marshal(), dst)
+	return err
+}
+
+// SendTo unicasts the packet's wire format to a specific server address,
+// used for renewals which RFC 2131 section 4.4.5 requires to be unicast.
+func (p *Packet) SendTo(conn *net.UDPConn, server net.IP) error {
+	dst := &net.UDPAddr{IP: server, Port: 67}
+	_, err := conn.WriteToUDP(p.Marshal(), dst)
+	return err
+}
+
+// Reply sends p, a server's OFFER/ACK/NAK, back towards the client,
+// following RFC 2131 section 4.1: if p carries a relay agent address
+// (Giaddr set), unicast to the relay agent on port 67 so it can forward
+// the reply; otherwise, if the broadcast flag is clear and p has a
+// Yiaddr, unicast to that address on port 68; otherwise broadcast on
+// port 68.
+func (p *Packet) Reply(conn *net.UDPConn) error {
+	const broadcastFlag = 0x8000
+
+	dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+	if !p.Giaddr.isZero() {
+		dst = &net.UDPAddr{IP: net.IP(p.Giaddr[:]), Port: 67}
+	} else if p.Flags&broadcastFlag == 0 && !p.Yiaddr.isZero() {
+		dst = &net.UDPAddr{IP: net.IP(p.Yiaddr[:]), Port: 68}
+	}
+
+	_, err := conn.WriteToUDP(p.Marshal(), dst)
+	return err
+}
+
+// Receive reads and decodes a single packet from conn, giving up after
+// timeout has elapsed since it was called.
+func (p *Packet) Receive(conn *net.UDPConn, timeout time.Duration) (*net.UDPAddr, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	buf := make([]byte, 1500)
+	n, remote, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Unmarshal(buf[:n]); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// DiscoverPacket is a DHCPDISCOVER packet ready to be customized and sent.
+type DiscoverPacket struct {
+	Packet
+}
+
+// NewDiscoverPacket returns a DHCPDISCOVER packet with a random
+// transaction ID and the DHCPMessageType option already set.
+func NewDiscoverPacket() *DiscoverPacket {
+	p := &DiscoverPacket{
+		Packet: Packet{
+			Op:    1, // BOOTREQUEST
+			Htype: 1, // Ethernet
+			Hlen:  6,
+			Xid:   newXid(),
+			Flags: 0x8000, // broadcast
+			Options: []byte{
+				DHCPMessageType, 1, DHCPDiscover,
+				EndOption,
+			},
+		},
+	}
+	return p
+}
+
+// ParseMAC sets the packet's hardware address from its string
+// representation (e.g. "aa:bb:cc:dd:ee:ff").
+func (p *Packet) ParseMAC(mac string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return err
+	}
+	if len(hw) > len(p.Chaddr) {
+		return fmt.Errorf("dhcp: hardware address too long (%d bytes)", len(hw))
+	}
+	copy(p.Chaddr[:], hw)
+	p.Hlen = byte(len(hw))
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// rawConnBSD sends and receives BOOTP/DHCP frames through a /dev/bpf
+// device, the BSD/macOS equivalent of Linux's AF_PACKET sockets.
+type rawConnBSD struct {
+	f   *os.File
+	mac net.HardwareAddr
+}
+
+func newRawConn(ifName string) (Conn, error) {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := openBPFDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bindBPFToInterface(f, ifName); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := attachDHCPFilterBSD(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &rawConnBSD{f: f, mac: ifi.HardwareAddr}, nil
+}
+
+// openBPFDevice tries /dev/bpf0, /dev/bpf1, ... since each /dev/bpfN
+// node only supports one open client at a time.
+func openBPFDevice() (*os.File, error) {
+	for i := 0; i < 32; i++ {
+		f, err := os.OpenFile(fmt.Sprintf("/dev/bpf%d", i), os.O_RDWR, 0)
+		if err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("dhcp: no free /dev/bpf device")
+}
+
+func bindBPFToInterface(f *os.File, ifName string) error {
+	var ifreq unix.IfreqData
+	copy(ifreq.Name[:], ifName)
+
+	if err := ioctl(f.Fd(), unix.BIOCSETIF, unsafe.Pointer(&ifreq)); err != nil {
+		return fmt.Errorf("dhcp: BIOCSETIF %s: %w", ifName, err)
+	}
+
+	one := int32(1)
+	if err := ioctl(f.Fd(), unix.BIOCIMMEDIATE, unsafe.Pointer(&one)); err != nil {
+		return fmt.Errorf("dhcp: BIOCIMMEDIATE: %w", err)
+	}
+	return nil
+}
+
+// attachDHCPFilterBSD installs the same classic BPF program as the
+// Linux backend (udp dst port 68), via BIOCSETF.
+func attachDHCPFilterBSD(f *os.File) error {
+	const (
+		bpfLdhAbs  = 0x28
+		bpfLdbAbs  = 0x30
+		bpfLdhInd  = 0x48
+		bpfLdxbMsh = 0xb1
+		bpfJeqK    = 0x15
+		bpfJsetK   = 0x45
+		bpfRetK    = 0x06
+	)
+
+	program := []unix.BpfInsn{
+		{Code: bpfLdhAbs, K: 12},
+		{Code: bpfJeqK, Jt: 0, Jf: 8, K: 0x0800},
+		{Code: bpfLdbAbs, K: 23},
+		{Code: bpfJeqK, Jt: 0, Jf: 6, K: 17},
+		{Code: bpfLdhAbs, K: 20},
+		{Code: bpfJsetK, Jt: 4, Jf: 0, K: 0x1fff},
+		{Code: bpfLdxbMsh, K: 14},
+		{Code: bpfLdhInd, K: 16},
+		{Code: bpfJeqK, Jt: 0, Jf: 1, K: clientPort},
+		{Code: bpfRetK, K: 0x40000},
+		{Code: bpfRetK, K: 0},
+	}
+
+	prog := unix.BpfProgram{
+		Len:   uint32(len(program)),
+		Insns: &program[0],
+	}
+	return ioctl(f.Fd(), unix.BIOCSETF, unsafe.Pointer(&prog))
+}
+
+func ioctl(fd uintptr, req uint, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, uintptr(req), uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (c *rawConnBSD) Send(p *Packet) error {
+	frame := buildBroadcastFrame(c.mac, p.Marshal())
+	_, err := c.f.Write(frame)
+	return err
+}
+
+func (c *rawConnBSD) Receive(timeout time.Duration) (*Packet, error) {
+	c.f.SetReadDeadline(time.Now().Add(timeout))
+
+	// /dev/bpf prefixes each captured frame with a bpf_hdr; read a
+	// generously sized buffer and let firstBPFFrame extract the first
+	// captured frame from it.
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := c.f.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		frame, err := firstBPFFrame(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		payload, err := parseBroadcastFrame(frame)
+		if err != nil {
+			continue
+		}
+
+		var p Packet
+		if err := p.Unmarshal(payload); err != nil {
+			continue
+		}
+		return &p, nil
+	}
+}
+
+// firstBPFFrame extracts the first captured frame from a /dev/bpf read
+// buffer, skipping the bpf_hdr the kernel prepends to each one.
+func firstBPFFrame(data []byte) ([]byte, error) {
+	hdr := unix.BpfHdr{}
+	hdrLen := int(unsafe.Sizeof(hdr))
+	if len(data) < hdrLen {
+		return nil, fmt.Errorf("dhcp: short bpf read")
+	}
+
+	hdr = *(*unix.BpfHdr)(unsafe.Pointer(&data[0]))
+	start := int(hdr.Hdrlen)
+	end := start + int(hdr.Caplen)
+	if end > len(data) {
+		return nil, fmt.Errorf("dhcp: truncated bpf frame")
+	}
+	return data[start:end], nil
+}
+
+func (c *rawConnBSD) Close() error {
+	return c.f.Close()
+}
@@ -0,0 +1,123 @@
+//go:build linux
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// rawConnLinux sends and receives BOOTP/DHCP frames on an AF_PACKET
+// raw socket, for use on interfaces with no IPv4 address configured.
+type rawConnLinux struct {
+	fd      int
+	ifIndex int
+	mac     net.HardwareAddr
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+func newRawConn(ifName string) (Conn, error) {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(syscall.ETH_P_IP)))
+	if err != nil {
+		return nil, fmt.Errorf("dhcp: raw socket: %w", err)
+	}
+
+	addr := syscall.SockaddrLinklayer{
+		Protocol: htons(syscall.ETH_P_IP),
+		Ifindex:  ifi.Index,
+	}
+	if err := syscall.Bind(fd, &addr); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("dhcp: bind %s: %w", ifName, err)
+	}
+
+	if err := attachDHCPFilter(fd); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return &rawConnLinux{fd: fd, ifIndex: ifi.Index, mac: ifi.HardwareAddr}, nil
+}
+
+// attachDHCPFilter installs a classic BPF program equivalent to
+// tcpdump's "udp and dst port 68", so the socket only wakes up for
+// BOOTP/DHCP client traffic instead of every frame on the interface.
+func attachDHCPFilter(fd int) error {
+	const (
+		bpfLdhAbs  = 0x28
+		bpfLdbAbs  = 0x30
+		bpfLdhInd  = 0x48
+		bpfLdxbMsh = 0xb1
+		bpfJeqK    = 0x15
+		bpfJsetK   = 0x45
+		bpfRetK    = 0x06
+	)
+
+	program := []syscall.SockFilter{
+		{Code: bpfLdhAbs, K: 12},                     // ldh [12] (ethertype)
+		{Code: bpfJeqK, Jt: 0, Jf: 8, K: 0x0800},     // jeq IPv4, else reject
+		{Code: bpfLdbAbs, K: 23},                     // ldb [23] (ip proto)
+		{Code: bpfJeqK, Jt: 0, Jf: 6, K: 17},         // jeq UDP, else reject
+		{Code: bpfLdhAbs, K: 20},                     // ldh [20] (flags+fragoff)
+		{Code: bpfJsetK, Jt: 4, Jf: 0, K: 0x1fff},    // fragmented, reject
+		{Code: bpfLdxbMsh, K: 14},                    // x = ip header length
+		{Code: bpfLdhInd, K: 16},                     // ldh [x+16] (udp dst port)
+		{Code: bpfJeqK, Jt: 0, Jf: 1, K: clientPort}, // jeq 68, else reject
+		{Code: bpfRetK, K: 0x40000},                  // accept
+		{Code: bpfRetK, K: 0},                        // reject
+	}
+
+	return syscall.AttachLsf(fd, program)
+}
+
+func (c *rawConnLinux) Send(p *Packet) error {
+	frame := buildBroadcastFrame(c.mac, p.Marshal())
+
+	addr := syscall.SockaddrLinklayer{
+		Ifindex: c.ifIndex,
+		Halen:   6,
+	}
+	copy(addr.Addr[:6], broadcastMAC)
+
+	return syscall.Sendto(c.fd, frame, 0, &addr)
+}
+
+func (c *rawConnLinux) Receive(timeout time.Duration) (*Packet, error) {
+	tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(c.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1600)
+	for {
+		n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err := parseBroadcastFrame(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		var p Packet
+		if err := p.Unmarshal(payload); err != nil {
+			continue
+		}
+		return &p, nil
+	}
+}
+
+func (c *rawConnLinux) Close() error {
+	return syscall.Close(c.fd)
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package dhcp
+
+import "fmt"
+
+func newRawConn(ifName string) (Conn, error) {
+	return nil, fmt.Errorf("dhcp: raw transport not supported on this platform")
+}
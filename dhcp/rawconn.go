@@ -0,0 +1,20 @@
+package dhcp
+
+import "time"
+
+// Conn is a transport for BOOTP/DHCP packets that mirrors the
+// Send/Receive shape of the UDP-socket code path in Packet, so callers
+// can swap one for the other. NewRawConn returns an implementation
+// that works even when the interface has no assigned IPv4 address.
+type Conn interface {
+	Send(p *Packet) error
+	Receive(timeout time.Duration) (*Packet, error)
+	Close() error
+}
+
+// NewRawConn opens a raw link-layer transport on ifName that
+// broadcasts full Ethernet+IPv4+UDP+BOOTP frames, for discovery on an
+// interface that has no IPv4 address configured yet.
+func NewRawConn(ifName string) (Conn, error) {
+	return newRawConn(ifName)
+}
@@ -0,0 +1,55 @@
+package dhcp
+
+import "fmt"
+
+// RelayAgentInfo is a decoded Relay Agent Information option (82, RFC
+// 3046).
+type RelayAgentInfo struct {
+	CircuitID []byte `json:"circuit_id,omitempty"`
+	RemoteID  []byte `json:"remote_id,omitempty"`
+}
+
+// BuildRelayAgentInfo encodes the circuit-id (sub-option 1) and
+// remote-id (sub-option 2) sub-TLVs of a Relay Agent Information
+// option. Either slice may be nil to omit that sub-option.
+func BuildRelayAgentInfo(circuitID, remoteID []byte) []byte {
+	var data []byte
+	if circuitID != nil {
+		data = append(data, CircuitID, byte(len(circuitID)))
+		data = append(data, circuitID...)
+	}
+	if remoteID != nil {
+		data = append(data, RemoteID, byte(len(remoteID)))
+		data = append(data, remoteID...)
+	}
+	return data
+}
+
+// DecodeRelayAgentInfo decodes the sub-TLVs of a Relay Agent
+// Information option.
+func DecodeRelayAgentInfo(data []byte) (*RelayAgentInfo, error) {
+	info := &RelayAgentInfo{}
+
+	for i := 0; i < len(data); {
+		if i+2 > len(data) {
+			return nil, fmt.Errorf("dhcp: relay agent info: truncated sub-option")
+		}
+		code := data[i]
+		length := int(data[i+1])
+		if i+2+length > len(data) {
+			return nil, fmt.Errorf("dhcp: relay agent info: truncated sub-option value")
+		}
+		value := data[i+2 : i+2+length]
+
+		switch code {
+		case CircuitID:
+			info.CircuitID = append([]byte(nil), value...)
+		case RemoteID:
+			info.RemoteID = append([]byte(nil), value...)
+		}
+
+		i += 2 + length
+	}
+
+	return info, nil
+}
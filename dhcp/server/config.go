@@ -0,0 +1,29 @@
+// Package server implements a minimal RFC 2131 DHCP server: it hands
+// out leases from a configurable address range, persists them to disk,
+// honors static reservations, and optionally probes an address with
+// ICMP before offering it to avoid conflicts.
+package server
+
+import (
+	"net"
+	"time"
+)
+
+// ServerConfig describes the network the server hands out leases for.
+type ServerConfig struct {
+	InterfaceName string
+	GatewayIP     net.IP
+	SubnetMask    net.IP
+	DNS           []net.IP
+	RangeStart    net.IP
+	RangeEnd      net.IP
+	LeaseDuration time.Duration
+
+	// ICMPTimeout is how long to wait for an echo reply before
+	// offering an address. Zero disables the probe.
+	ICMPTimeout time.Duration
+
+	// LeaseFile is where the lease database is persisted as JSON.
+	// If empty, leases are kept in memory only.
+	LeaseFile string
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"time"
+)
+
+// probeAddress sends an ICMP echo request to ip and reports whether a
+// reply was seen before timeout, meaning the address is already in use
+// and should not be offered. A zero timeout always reports false
+// (probing disabled).
+func probeAddress(ip net.IP, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+
+	conn, err := net.DialIP("ip4:icmp", nil, &net.IPAddr{IP: ip})
+	if err != nil {
+		// Most likely EPERM: raw sockets need CAP_NET_RAW. Fail
+		// open rather than refuse to offer addresses at all.
+		return false
+	}
+	defer conn.Close()
+
+	id := uint16(os.Getpid())
+	req := echoRequest(id, 1)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(req); err != nil {
+		return false
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return false
+		}
+		if isEchoReply(stripIPHeader(buf[:n]), id) {
+			return true
+		}
+	}
+}
+
+// stripIPHeader removes the IPv4 header net.DialIP("ip4:...") leaves in
+// place on read, so callers see the ICMP message starting at offset 0.
+func stripIPHeader(data []byte) []byte {
+	if len(data) < 1 {
+		return data
+	}
+	ihl := int(data[0]&0x0f) * 4
+	if ihl < 20 || ihl > len(data) {
+		return data
+	}
+	return data[ihl:]
+}
+
+// echoRequest builds an ICMP echo request with the given identifier
+// and sequence number.
+func echoRequest(id, seq uint16) []byte {
+	const icmpEchoRequest = 8
+
+	msg := make([]byte, 8)
+	msg[0] = icmpEchoRequest
+	msg[1] = 0 // code
+	binary.BigEndian.PutUint16(msg[4:6], id)
+	binary.BigEndian.PutUint16(msg[6:8], seq)
+
+	var sum uint32
+	for i := 0; i < len(msg); i += 2 {
+		sum += uint32(msg[i])<<8 | uint32(msg[i+1])
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	binary.BigEndian.PutUint16(msg[2:4], ^uint16(sum))
+
+	return msg
+}
+
+func isEchoReply(data []byte, id uint16) bool {
+	const icmpEchoReply = 0
+	if len(data) < 8 {
+		return false
+	}
+	return data[0] == icmpEchoReply && binary.BigEndian.Uint16(data[4:6]) == id
+}
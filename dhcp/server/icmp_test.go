@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestProbeAddressDisabledWhenTimeoutZero(t *testing.T) {
+	if probeAddress(net.ParseIP("192.0.2.1"), 0) {
+		t.Fatal("a zero timeout must disable probing and always report unused")
+	}
+}
+
+func TestProbeAddressFailsOpenOnNoReply(t *testing.T) {
+	// 192.0.2.1 is documentation space (RFC 5737): unrouted, so this
+	// either fails to open the raw socket (no CAP_NET_RAW) or times
+	// out waiting for a reply. Either way it must fail open rather
+	// than block indefinitely or report the address as in use.
+	if probeAddress(net.ParseIP("192.0.2.1"), 50*time.Millisecond) {
+		t.Fatal("probeAddress must fail open, not report an unreachable address as taken")
+	}
+}
+
+func TestProbeAddressDetectsResponder(t *testing.T) {
+	if _, err := net.DialIP("ip4:icmp", nil, &net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}); err != nil {
+		t.Skipf("raw ICMP sockets unavailable in this environment: %v", err)
+	}
+	if !probeAddress(net.ParseIP("127.0.0.1"), time.Second) {
+		t.Fatal("probeAddress must report 127.0.0.1 as in use, it always answers echo requests")
+	}
+}
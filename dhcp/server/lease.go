@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Lease is a single MAC-to-IP binding. A zero Expires marks a static
+// reservation that never expires.
+type Lease struct {
+	MAC      string    `json:"mac"`
+	IP       net.IP    `json:"ip"`
+	Hostname string    `json:"hostname,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Static reports whether the lease is a non-expiring reservation.
+func (l *Lease) Static() bool {
+	return l.Expires.IsZero()
+}
+
+// Expired reports whether a dynamic lease has passed its expiry time.
+func (l *Lease) Expired(now time.Time) bool {
+	return !l.Static() && now.After(l.Expires)
+}
+
+// LeaseStore tracks allocated leases, keyed by MAC address, and
+// optionally persists them to a JSON file.
+type LeaseStore struct {
+	path string
+
+	mu    sync.Mutex
+	byMAC map[string]*Lease
+	byIP  map[string]*Lease
+}
+
+// NewLeaseStore creates an empty store that persists to path. An empty
+// path keeps leases in memory only.
+func NewLeaseStore(path string) *LeaseStore {
+	return &LeaseStore{
+		path:  path,
+		byMAC: make(map[string]*Lease),
+		byIP:  make(map[string]*Lease),
+	}
+}
+
+// Load reads the lease database from disk. It is not an error for the
+// file not to exist yet.
+func (s *LeaseStore) Load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var leases []*Lease
+	if err := json.Unmarshal(data, &leases); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range leases {
+		s.byMAC[l.MAC] = l
+		s.byIP[l.IP.String()] = l
+	}
+	return nil
+}
+
+// save writes the lease database to disk. Callers must hold s.mu.
+func (s *LeaseStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	leases := make([]*Lease, 0, len(s.byMAC))
+	for _, l := range s.byMAC {
+		leases = append(leases, l)
+	}
+
+	data, err := json.MarshalIndent(leases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+// Reserve installs a static, non-expiring lease, e.g. from
+// configuration at startup.
+func (s *LeaseStore) Reserve(mac string, ip net.IP, hostname string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := &Lease{MAC: mac, IP: ip, Hostname: hostname}
+	s.byMAC[mac] = l
+	s.byIP[ip.String()] = l
+	return s.save()
+}
+
+// ByMAC returns the lease for mac, if any.
+func (s *LeaseStore) ByMAC(mac string) (*Lease, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.byMAC[mac]
+	return l, ok
+}
+
+// Taken reports whether ip is currently held by an unexpired lease
+// other than mac's own.
+func (s *LeaseStore) Taken(ip net.IP, mac string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.byIP[ip.String()]
+	if !ok || l.MAC == mac {
+		return false
+	}
+	return l.Static() || !l.Expired(now)
+}
+
+// Allocate records a new lease for mac/ip, expiring after duration
+// (ignored for a pre-existing static reservation of the same MAC).
+func (s *LeaseStore) Allocate(mac string, ip net.IP, hostname string, duration time.Duration, now time.Time) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byMAC[mac]; ok && existing.Static() {
+		return existing, nil
+	}
+
+	l := &Lease{MAC: mac, IP: ip, Hostname: hostname, Expires: now.Add(duration)}
+	s.byMAC[mac] = l
+	s.byIP[ip.String()] = l
+	return l, s.save()
+}
+
+// Release removes a dynamic lease for mac. Static reservations are
+// left untouched, matching the expectation that they outlive a
+// DHCPRELEASE.
+func (s *LeaseStore) Release(mac string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.byMAC[mac]
+	if !ok || l.Static() {
+		return nil
+	}
+	delete(s.byMAC, mac)
+	delete(s.byIP, l.IP.String())
+	return s.save()
+}
+
+func cloneIP(ip net.IP) net.IP {
+	ip4 := ip.To4()
+	out := make(net.IP, 4)
+	copy(out, ip4)
+	return out
+}
+
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+}
+
+func ipGreater(a, b net.IP) bool {
+	a4, b4 := a.To4(), b.To4()
+	for i := 0; i < 4; i++ {
+		if a4[i] != b4[i] {
+			return a4[i] > b4[i]
+		}
+	}
+	return false
+}
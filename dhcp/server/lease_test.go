@@ -0,0 +1,143 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLeaseStoreAllocateAndTaken(t *testing.T) {
+	s := NewLeaseStore("")
+	now := time.Now()
+	ip := net.ParseIP("192.0.2.10")
+
+	if s.Taken(ip, "aa:bb:cc:dd:ee:01", now) {
+		t.Fatal("fresh store reports an untaken address as taken")
+	}
+
+	if _, err := s.Allocate("aa:bb:cc:dd:ee:01", ip, "host1", time.Hour, now); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	if !s.Taken(ip, "aa:bb:cc:dd:ee:02", now) {
+		t.Fatal("address held by another MAC should be taken")
+	}
+	if s.Taken(ip, "aa:bb:cc:dd:ee:01", now) {
+		t.Fatal("a MAC's own lease should not count as taken")
+	}
+
+	later := now.Add(2 * time.Hour)
+	if s.Taken(ip, "aa:bb:cc:dd:ee:02", later) {
+		t.Fatal("expired lease should no longer be taken")
+	}
+}
+
+func TestLeaseStoreReserveIsStaticAndSurvivesRelease(t *testing.T) {
+	s := NewLeaseStore("")
+	ip := net.ParseIP("192.0.2.20")
+	if err := s.Reserve("aa:bb:cc:dd:ee:03", ip, "printer"); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	l, ok := s.ByMAC("aa:bb:cc:dd:ee:03")
+	if !ok || !l.Static() {
+		t.Fatal("reserved lease should be present and static")
+	}
+
+	if err := s.Release("aa:bb:cc:dd:ee:03"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok := s.ByMAC("aa:bb:cc:dd:ee:03"); !ok {
+		t.Fatal("Release must not remove a static reservation")
+	}
+}
+
+func TestLeaseStoreReleaseDynamicLease(t *testing.T) {
+	s := NewLeaseStore("")
+	now := time.Now()
+	ip := net.ParseIP("192.0.2.30")
+
+	if _, err := s.Allocate("aa:bb:cc:dd:ee:04", ip, "", time.Hour, now); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if err := s.Release("aa:bb:cc:dd:ee:04"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok := s.ByMAC("aa:bb:cc:dd:ee:04"); ok {
+		t.Fatal("Release should remove a dynamic lease")
+	}
+	if s.Taken(ip, "aa:bb:cc:dd:ee:05", now) {
+		t.Fatal("released address should no longer be taken")
+	}
+}
+
+func TestServerAddressForRangeExhaustion(t *testing.T) {
+	s := &Server{
+		cfg: ServerConfig{
+			RangeStart: net.ParseIP("192.0.2.100"),
+			RangeEnd:   net.ParseIP("192.0.2.101"),
+		},
+		leases: NewLeaseStore(""),
+	}
+	now := time.Now()
+
+	if _, err := s.leases.Allocate("aa:bb:cc:dd:ee:06", net.ParseIP("192.0.2.100"), "", time.Hour, now); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if _, err := s.leases.Allocate("aa:bb:cc:dd:ee:07", net.ParseIP("192.0.2.101"), "", time.Hour, now); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	if _, err := s.addressFor("aa:bb:cc:dd:ee:08", now); err == nil {
+		t.Fatal("addressFor should fail once the range is exhausted")
+	}
+}
+
+func TestServerRequestableRejectsOutOfRangeAddress(t *testing.T) {
+	s := &Server{
+		cfg: ServerConfig{
+			RangeStart: net.ParseIP("192.0.2.100"),
+			RangeEnd:   net.ParseIP("192.0.2.110"),
+		},
+		leases: NewLeaseStore(""),
+	}
+	now := time.Now()
+
+	if s.requestable(net.ParseIP("192.0.2.200"), "aa:bb:cc:dd:ee:09", now) {
+		t.Fatal("requestable must reject an address outside the configured range")
+	}
+}
+
+func TestServerRequestableAllowsOwnLeaseOutsideRange(t *testing.T) {
+	s := &Server{
+		cfg: ServerConfig{
+			RangeStart: net.ParseIP("192.0.2.100"),
+			RangeEnd:   net.ParseIP("192.0.2.110"),
+		},
+		leases: NewLeaseStore(""),
+	}
+	now := time.Now()
+	ip := net.ParseIP("192.0.2.200")
+
+	if err := s.leases.Reserve("aa:bb:cc:dd:ee:0a", ip, ""); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if !s.requestable(ip, "aa:bb:cc:dd:ee:0a", now) {
+		t.Fatal("requestable must allow renewing a static reservation outside the pool range")
+	}
+}
+
+func TestServerRequestableAllowsInRangeFreeAddress(t *testing.T) {
+	s := &Server{
+		cfg: ServerConfig{
+			RangeStart: net.ParseIP("192.0.2.100"),
+			RangeEnd:   net.ParseIP("192.0.2.110"),
+		},
+		leases: NewLeaseStore(""),
+	}
+	now := time.Now()
+
+	if !s.requestable(net.ParseIP("192.0.2.105"), "aa:bb:cc:dd:ee:0b", now) {
+		t.Fatal("requestable must allow a free in-range address")
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	".."
+)
+
+// Server answers DHCP requests on :67 for a single subnet.
+type Server struct {
+	cfg    ServerConfig
+	leases *LeaseStore
+}
+
+// NewServer creates a Server for cfg, loading any existing lease
+// database from cfg.LeaseFile.
+func NewServer(cfg ServerConfig) (*Server, error) {
+	s := &Server{
+		cfg:    cfg,
+		leases: NewLeaseStore(cfg.LeaseFile),
+	}
+	if err := s.leases.Load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reserve installs a static reservation, overriding anything the
+// dynamic pool would otherwise hand out for mac.
+func (s *Server) Reserve(mac string, ip net.IP, hostname string) error {
+	return s.leases.Reserve(mac, ip, hostname)
+}
+
+// ListenAndServe binds UDP port 67 and serves requests until it returns
+// an error.
+func (s *Server) ListenAndServe() error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: 67})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1500)
+	for {
+		n, remote, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+
+		var p dhcp.Packet
+		if err := p.Unmarshal(buf[:n]); err != nil {
+			log.Printf("server: %s", err)
+			continue
+		}
+
+		if err := s.handle(conn, remote, &p); err != nil {
+			log.Printf("server: %s", err)
+		}
+	}
+}
+
+func (s *Server) handle(conn *net.UDPConn, remote *net.UDPAddr, p *dhcp.Packet) error {
+	mac := net.HardwareAddr(p.Chaddr[:p.Hlen]).String()
+
+	switch p.MessageType() {
+	case dhcp.DHCPDiscover:
+		return s.handleDiscover(conn, p, mac)
+	case dhcp.DHCPRequest:
+		return s.handleRequest(conn, p, mac)
+	case dhcp.DHCPDecline:
+		return s.handleDecline(p, mac)
+	case dhcp.DHCPRelease:
+		return s.leases.Release(mac)
+	}
+	return nil
+}
+
+func (s *Server) handleDiscover(conn *net.UDPConn, p *dhcp.Packet, mac string) error {
+	now := time.Now()
+
+	ip, err := s.addressFor(mac, now)
+	if err != nil {
+		return err
+	}
+
+	reply := s.buildReply(p, dhcp.DHCPOffer, ip)
+	return reply.Reply(conn)
+}
+
+// addressFor returns the address to offer/ack for mac: its static
+// reservation if any, its existing lease if still valid, or the next
+// free address in the configured range, skipping any that answer an
+// ICMP probe.
+func (s *Server) addressFor(mac string, now time.Time) (net.IP, error) {
+	if l, ok := s.leases.ByMAC(mac); ok && (l.Static() || !l.Expired(now)) {
+		return l.IP, nil
+	}
+
+	for ip := cloneIP(s.cfg.RangeStart); !ipGreater(ip, s.cfg.RangeEnd); incIP(ip) {
+		candidate := cloneIP(ip)
+		if s.leases.Taken(candidate, mac, now) {
+			continue
+		}
+		if probeAddress(candidate, s.cfg.ICMPTimeout) {
+			continue
+		}
+		return candidate, nil
+	}
+	return nil, fmt.Errorf("server: no free address for %s", mac)
+}
+
+func (s *Server) handleRequest(conn *net.UDPConn, p *dhcp.Packet, mac string) error {
+	now := time.Now()
+
+	requested := p.Ciaddr[:]
+	if data, ok := p.GetOption(dhcp.RequestedIPAddress); ok {
+		requested = data
+	}
+	ip := net.IP(requested)
+
+	if s.leases.Taken(ip, mac, now) || !s.requestable(ip, mac, now) {
+		nak := s.buildReply(p, dhcp.DHCPNack, nil)
+		return nak.Reply(conn)
+	}
+
+	l, err := s.leases.Allocate(mac, ip, "", s.cfg.LeaseDuration, now)
+	if err != nil {
+		return err
+	}
+
+	reply := s.buildReply(p, dhcp.DHCPAck, l.IP)
+	return reply.Reply(conn)
+}
+
+// requestable reports whether mac may REQUEST ip: its own existing
+// lease or static reservation, or an in-range address that doesn't
+// answer an ICMP probe. This mirrors the checks addressFor applies for
+// DISCOVER, so a client can't sidestep them by REQUESTing an address it
+// was never offered.
+func (s *Server) requestable(ip net.IP, mac string, now time.Time) bool {
+	if l, ok := s.leases.ByMAC(mac); ok && l.IP.Equal(ip) && (l.Static() || !l.Expired(now)) {
+		return true
+	}
+	if !s.inRange(ip) {
+		return false
+	}
+	return !probeAddress(ip, s.cfg.ICMPTimeout)
+}
+
+// inRange reports whether ip falls within the server's configured
+// dynamic pool.
+func (s *Server) inRange(ip net.IP) bool {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false
+	}
+	return !ipGreater(s.cfg.RangeStart, ip4) && !ipGreater(ip4, s.cfg.RangeEnd)
+}
+
+func (s *Server) handleDecline(p *dhcp.Packet, mac string) error {
+	return s.leases.Release(mac)
+}
+
+// buildReply constructs an OFFER/ACK/NAK in response to p, filling in
+// the configured gateway, subnet mask, DNS servers and lease time.
+func (s *Server) buildReply(p *dhcp.Packet, msgType byte, yiaddr net.IP) *dhcp.Packet {
+	reply := &dhcp.Packet{
+		Op:     2, // BOOTREPLY
+		Htype:  p.Htype,
+		Hlen:   p.Hlen,
+		Xid:    p.Xid,
+		Flags:  p.Flags,
+		Giaddr: p.Giaddr,
+		Chaddr: p.Chaddr,
+		Options: []byte{
+			dhcp.DHCPMessageType, 1, msgType,
+			dhcp.EndOption,
+		},
+	}
+
+	if yiaddr != nil {
+		copy(reply.Yiaddr[:], yiaddr.To4())
+	}
+
+	if msgType != dhcp.DHCPNack {
+		if s.cfg.SubnetMask != nil {
+			reply.AddOption(dhcp.SubnetMask, s.cfg.SubnetMask.To4())
+		}
+		if s.cfg.GatewayIP != nil {
+			reply.AddOption(dhcp.Router, s.cfg.GatewayIP.To4())
+		}
+		if len(s.cfg.DNS) > 0 {
+			data := make([]byte, 0, 4*len(s.cfg.DNS))
+			for _, ip := range s.cfg.DNS {
+				data = append(data, ip.To4()...)
+			}
+			reply.AddOption(dhcp.DomainNameServer, data)
+		}
+
+		lease := make([]byte, 4)
+		binary.BigEndian.PutUint32(lease, uint32(s.cfg.LeaseDuration/time.Second))
+		reply.AddOption(dhcp.IPAddressLeaseTime, lease)
+	}
+
+	return reply
+}
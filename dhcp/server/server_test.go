@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	".."
+)
+
+// TestServerDiscoverRequestAck drives a full DISCOVER -> OFFER -> REQUEST
+// -> ACK exchange against a real Server over loopback, using the actual
+// well-known DHCP ports since Packet.Reply hardcodes them.
+func TestServerDiscoverRequestAck(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		RangeStart:    net.ParseIP("192.0.2.10"),
+		RangeEnd:      net.ParseIP("192.0.2.20"),
+		LeaseDuration: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	srvConn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: 67})
+	if err != nil {
+		t.Skipf("cannot bind :67 in this environment: %v", err)
+	}
+	defer srvConn.Close()
+
+	clientConn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: 68})
+	if err != nil {
+		t.Skipf("cannot bind :68 in this environment: %v", err)
+	}
+	defer clientConn.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, remote, err := srvConn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var p dhcp.Packet
+			if err := p.Unmarshal(buf[:n]); err != nil {
+				continue
+			}
+			s.handle(srvConn, remote, &p)
+		}
+	}()
+
+	discover := dhcp.NewDiscoverPacket()
+	if err := discover.ParseMAC("aa:bb:cc:dd:ee:ff"); err != nil {
+		t.Fatalf("ParseMAC: %v", err)
+	}
+	if err := discover.SendFrom(clientConn); err != nil {
+		t.Fatalf("send DISCOVER: %v", err)
+	}
+
+	var offer dhcp.Packet
+	if _, err := offer.Receive(clientConn, time.Second); err != nil {
+		t.Fatalf("receive OFFER: %v", err)
+	}
+	if offer.MessageType() != dhcp.DHCPOffer {
+		t.Fatalf("expected DHCPOFFER, got message type %d", offer.MessageType())
+	}
+	offeredIP := net.IP(offer.Yiaddr[:])
+
+	req := dhcp.Packet{
+		Op:    1,
+		Htype: 1,
+		Hlen:  6,
+		Xid:   offer.Xid,
+		Flags: 0x8000, // broadcast: client has no IP to receive a unicast ACK on
+		Options: []byte{
+			dhcp.DHCPMessageType, 1, dhcp.DHCPRequest,
+			dhcp.EndOption,
+		},
+	}
+	if err := req.ParseMAC("aa:bb:cc:dd:ee:ff"); err != nil {
+		t.Fatalf("ParseMAC: %v", err)
+	}
+	req.AddOption(dhcp.RequestedIPAddress, offer.Yiaddr[:])
+	if err := req.SendFrom(clientConn); err != nil {
+		t.Fatalf("send REQUEST: %v", err)
+	}
+
+	var ack dhcp.Packet
+	if _, err := ack.Receive(clientConn, time.Second); err != nil {
+		t.Fatalf("receive ACK: %v", err)
+	}
+	if ack.MessageType() != dhcp.DHCPAck {
+		t.Fatalf("expected DHCPACK, got message type %d", ack.MessageType())
+	}
+	if !net.IP(ack.Yiaddr[:]).Equal(offeredIP) {
+		t.Fatalf("ACK yiaddr %s does not match offered %s", net.IP(ack.Yiaddr[:]), offeredIP)
+	}
+}
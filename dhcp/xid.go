@@ -0,0 +1,9 @@
+package dhcp
+
+import "math/rand"
+
+// newXid returns a random transaction ID for a new client-originated
+// packet, as required by RFC 2131 section 4.1.
+func newXid() uint32 {
+	return rand.Uint32()
+}
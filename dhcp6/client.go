@@ -0,0 +1,220 @@
+package dhcp6
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// Lease is the decoded result of a SOLICIT/REPLY exchange.
+type Lease struct {
+	ServerID  []byte
+	Addresses []net.IP
+	DNS       []net.IP
+	Domains   []string
+}
+
+// Client implements the DHCPv6 client exchanges needed to acquire a
+// lease on a single interface: SOLICIT -> ADVERTISE -> REQUEST ->
+// REPLY (RFC 8415 section 18).
+type Client struct {
+	Iface string
+	DUID  []byte
+	IAID  uint32
+
+	conn *net.UDPConn
+}
+
+// NewClient returns a Client bound to iface, deriving a DUID-LLT from
+// the interface's hardware address.
+func NewClient(iface string) (*Client, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Iface: iface,
+		DUID:  NewDUIDLLT(ifi.HardwareAddr),
+		IAID:  rand.Uint32(),
+	}, nil
+}
+
+// Discover runs SOLICIT -> pick first ADVERTISE -> REQUEST -> REPLY
+// and returns the decoded lease.
+func (c *Client) Discover(ctx context.Context) (*Lease, error) {
+	ifi, err := net.InterfaceByName(c.Iface)
+	if err != nil {
+		return nil, err
+	}
+
+	// The kernel picks the link-local source address for the given
+	// zone; binding the unspecified address here is enough.
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{Port: ClientPort, Zone: c.Iface})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	c.conn = conn
+
+	dst := &net.UDPAddr{
+		IP:   net.ParseIP(AllDHCPRelayAgentsAndServers),
+		Port: ServerPort,
+		Zone: ifi.Name,
+	}
+
+	advertise, err := c.solicit(ctx, conn, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.request(ctx, conn, dst, advertise)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeLease(reply), nil
+}
+
+func (c *Client) solicit(ctx context.Context, conn *net.UDPConn, dst *net.UDPAddr) (*Message, error) {
+	msg := c.newMessage(Solicit)
+	msg.AddOption(OptIANA, iaNA(c.IAID, 0, 0, nil))
+
+	for attempt := 0; ; attempt++ {
+		if _, err := conn.WriteToUDP(msg.Marshal(), dst); err != nil {
+			return nil, err
+		}
+
+		reply, err := receive(conn, retransmitDelay(attempt))
+		if err == nil && reply.Type == Advertise && reply.TransactionID == msg.TransactionID {
+			return reply, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+}
+
+func (c *Client) request(ctx context.Context, conn *net.UDPConn, dst *net.UDPAddr, advertise *Message) (*Message, error) {
+	serverID, ok := advertise.GetOption(OptServerID)
+	if !ok {
+		return nil, fmt.Errorf("dhcp6: advertise missing Server Identifier")
+	}
+
+	msg := c.newMessage(Request)
+	msg.AddOption(OptServerID, serverID)
+	if ia, ok := advertise.GetOption(OptIANA); ok {
+		msg.AddOption(OptIANA, ia)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if _, err := conn.WriteToUDP(msg.Marshal(), dst); err != nil {
+			return nil, err
+		}
+
+		reply, err := receive(conn, retransmitDelay(attempt))
+		if err == nil && reply.Type == Reply && reply.TransactionID == msg.TransactionID {
+			return reply, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+}
+
+func (c *Client) newMessage(msgType byte) *Message {
+	msg := &Message{Type: msgType}
+	rand.Read(msg.TransactionID[:])
+
+	msg.AddOption(OptClientID, c.DUID)
+
+	elapsed := make([]byte, 2)
+	msg.AddOption(OptElapsedTime, elapsed)
+
+	return msg
+}
+
+func receive(conn *net.UDPConn, timeout time.Duration) (*Message, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	buf := make([]byte, 1500)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Message
+	if err := m.Unmarshal(buf[:n]); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// retransmitDelay mirrors the v4 client's exponential backoff: 2s, 4s,
+// 8s... capped at 64s.
+func retransmitDelay(attempt int) time.Duration {
+	d := 2 * time.Second
+	for i := 0; i < attempt; i++ {
+		d *= 2
+		if d >= 64*time.Second {
+			return 64 * time.Second
+		}
+	}
+	return d
+}
+
+// iaNA builds an IA_NA option body (RFC 8415 section 21.4): IAID, T1,
+// T2, followed by any nested options (e.g. an IA Address to request).
+func iaNA(iaid uint32, t1, t2 uint32, nested []byte) []byte {
+	body := make([]byte, 12+len(nested))
+	binary.BigEndian.PutUint32(body[0:4], iaid)
+	binary.BigEndian.PutUint32(body[4:8], t1)
+	binary.BigEndian.PutUint32(body[8:12], t2)
+	copy(body[12:], nested)
+	return body
+}
+
+func decodeLease(reply *Message) *Lease {
+	lease := &Lease{}
+	lease.ServerID, _ = reply.GetOption(OptServerID)
+
+	if ia, ok := reply.GetOption(OptIANA); ok && len(ia) >= 12 {
+		opts := ia[12:]
+		for i := 0; i+4 <= len(opts); {
+			code := uint16(opts[i])<<8 | uint16(opts[i+1])
+			length := int(uint16(opts[i+2])<<8 | uint16(opts[i+3]))
+			if i+4+length > len(opts) {
+				break
+			}
+			if code == OptIAAddr && length >= 16 {
+				lease.Addresses = append(lease.Addresses, net.IP(opts[i+4:i+4+16]))
+			}
+			i += 4 + length
+		}
+	}
+
+	if data, ok := reply.GetOption(OptDNSServers); ok {
+		lease.DNS = DecodeDNSServers(data)
+	}
+
+	var domainData []byte
+	for _, d := range reply.GetOptions(OptDomainSearchList) {
+		domainData = append(domainData, d...)
+	}
+	if domainData != nil {
+		if domains, err := DecodeDomainSearchList(domainData); err == nil {
+			lease.Domains = domains
+		}
+	}
+
+	return lease
+}
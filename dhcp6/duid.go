@@ -0,0 +1,23 @@
+package dhcp6
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+// duidEpoch is midnight (UTC), January 1, 2000, the epoch DUID-LLT
+// timestamps are measured from (RFC 8415 section 11.2).
+var duidEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NewDUIDLLT builds a DUID-LLT (link-layer address plus time, RFC 8415
+// section 11.2) for an Ethernet interface with the given hardware
+// address.
+func NewDUIDLLT(hw net.HardwareAddr) []byte {
+	duid := make([]byte, 8+len(hw))
+	binary.BigEndian.PutUint16(duid[0:2], 1) // DUID-LLT
+	binary.BigEndian.PutUint16(duid[2:4], 1) // hardware type: Ethernet
+	binary.BigEndian.PutUint32(duid[4:8], uint32(time.Since(duidEpoch).Seconds()))
+	copy(duid[8:], hw)
+	return duid
+}
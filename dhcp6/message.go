@@ -0,0 +1,127 @@
+// Package dhcp6 implements enough of RFC 8415 (DHCPv6) to solicit and
+// request an address lease: SOLICIT/ADVERTISE/REQUEST/REPLY over
+// UDP/547<->546 with link-local sourcing.
+package dhcp6
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Message types, RFC 8415 section 7.3.
+const (
+	Solicit            = 1
+	Advertise          = 2
+	Request            = 3
+	Confirm            = 4
+	Renew              = 5
+	Rebind             = 6
+	Reply              = 7
+	Release            = 8
+	Decline            = 9
+	Reconfigure        = 10
+	InformationRequest = 11
+	RelayForw          = 12
+	RelayRepl          = 13
+)
+
+// Option codes used by this package, RFC 8415 section 21 and RFC 3646.
+const (
+	OptClientID         = 1
+	OptServerID         = 2
+	OptIANA             = 3
+	OptIAAddr           = 5
+	OptOptionRequest    = 6
+	OptElapsedTime      = 8
+	OptStatusCode       = 13
+	OptDNSServers       = 23
+	OptDomainSearchList = 24
+)
+
+// ClientPort and ServerPort are the well-known DHCPv6 UDP ports.
+const (
+	ClientPort = 546
+	ServerPort = 547
+)
+
+// AllDHCPRelayAgentsAndServers is the All_DHCP_Relay_Agents_and_Servers
+// multicast address, ff02::1:2.
+const AllDHCPRelayAgentsAndServers = "ff02::1:2"
+
+// Message is a DHCPv6 message as defined by RFC 8415 section 8.
+type Message struct {
+	Type          byte
+	TransactionID [3]byte
+	Options       []byte
+}
+
+// Marshal encodes the message into its wire format.
+func (m *Message) Marshal() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(m.Type)
+	buf.Write(m.TransactionID[:])
+	buf.Write(m.Options)
+	return buf.Bytes()
+}
+
+// Unmarshal decodes a wire-format message into m.
+func (m *Message) Unmarshal(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("dhcp6: message too short (%d bytes)", len(data))
+	}
+	m.Type = data[0]
+	copy(m.TransactionID[:], data[1:4])
+	m.Options = append([]byte(nil), data[4:]...)
+	return nil
+}
+
+// AddOption appends a 2-byte code, 2-byte length, and data to the
+// option list, per RFC 8415 section 21.1.
+func (m *Message) AddOption(code uint16, data []byte) {
+	opt := make([]byte, 4+len(data))
+	opt[0] = byte(code >> 8)
+	opt[1] = byte(code)
+	opt[2] = byte(len(data) >> 8)
+	opt[3] = byte(len(data))
+	copy(opt[4:], data)
+	m.Options = append(m.Options, opt...)
+}
+
+// GetOption returns the raw value of the first instance of option code
+// in m.Options, and whether it was present.
+func (m *Message) GetOption(code uint16) ([]byte, bool) {
+	opts := m.Options
+	for i := 0; i+4 <= len(opts); {
+		c := uint16(opts[i])<<8 | uint16(opts[i+1])
+		length := int(uint16(opts[i+2])<<8 | uint16(opts[i+3]))
+		if i+4+length > len(opts) {
+			return nil, false
+		}
+		if c == code {
+			return opts[i+4 : i+4+length], true
+		}
+		i += 4 + length
+	}
+	return nil, false
+}
+
+// GetOptions returns every instance of option code in m.Options, in
+// order, as separate slices; for multi-instance options like Domain
+// Search List (RFC 3397/8415 section 21.24) the caller concatenates
+// them.
+func (m *Message) GetOptions(code uint16) [][]byte {
+	var out [][]byte
+	opts := m.Options
+	for i := 0; i+4 <= len(opts); {
+		c := uint16(opts[i])<<8 | uint16(opts[i+1])
+		length := int(uint16(opts[i+2])<<8 | uint16(opts[i+3]))
+		if i+4+length > len(opts) {
+			break
+		}
+		if c == code {
+			out = append(out, opts[i+4:i+4+length])
+		}
+		i += 4 + length
+	}
+	return out
+}
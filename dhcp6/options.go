@@ -0,0 +1,24 @@
+package dhcp6
+
+import (
+	"net"
+
+	"../dhcp"
+)
+
+// DecodeDNSServers decodes the DNS Recursive Name Server option (23,
+// RFC 3646): a list of 16-byte IPv6 addresses.
+func DecodeDNSServers(data []byte) []net.IP {
+	var servers []net.IP
+	for i := 0; i+16 <= len(data); i += 16 {
+		servers = append(servers, net.IP(data[i:i+16]))
+	}
+	return servers
+}
+
+// DecodeDomainSearchList decodes the Domain Search List option (24,
+// RFC 3646), which uses the same RFC 1035 section 4.1.4 name
+// compression as DHCPv4's option 119.
+func DecodeDomainSearchList(data []byte) ([]string, error) {
+	return dhcp.DecodeDomainSearch(data)
+}
@@ -2,140 +2,59 @@ package main
 
 import (
 	"./dhcp"
-	"bytes"
-	"encoding/binary"
+	"./dhcp6"
+	"context"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"time"
 )
 
-type option struct {
-	Len  int
-	Name string
+// showPacket renders p in outFormat and prints it to stdout.
+func showPacket(p *dhcp.Packet, outFormat string) {
+	text, err := formatPacket(p, outFormat)
+	checkError(err)
+	fmt.Print(text)
 }
 
-var options map[byte]option
-var messageType map[byte]string
-
-func init() {
-	options = map[byte]option{
-		dhcp.PadOption:          {0, "Pad Option"},
-		dhcp.Router:             {-1, "Router"},
-		dhcp.SubnetMask:         {4, "Subnet Mask"},
-		dhcp.DomainNameServer:   {-1, "Domain Name Server"},
-		dhcp.HostName:           {-1, "Host Name"},
-		dhcp.DomainName:         {-1, "Domain Name"},
-		dhcp.BroadcastAddress:   {4, "Broadcast Address"},
-		dhcp.StaticRoute:        {-1, "Static Route"},
-		dhcp.IPAddressLeaseTime: {4, "IP Address Lease Time"},
-		dhcp.DHCPMessageType:    {1, "DHCP Message Type"},
-		dhcp.ServerIdentifier:   {4, "Server Identifier"},
-		dhcp.RenewalTimeValue:   {4, "Renewal Time Value"},
-		dhcp.RebindingTimeValue: {4, "Rebinding Time Value"},
-		dhcp.VendorSpecific:     {-1, "Vendor Specific"},
-		dhcp.NetBIOSNameServer:  {-1, "NetBIOS Name Server"},
-		dhcp.DomainSearch:       {-1, "Domain Search"},
-		dhcp.WebProxyServer:     {-1, "Web Proxy Server"},
+func showLease6(lease *dhcp6.Lease) {
+	fmt.Println("Server Identifier :", fmt.Sprintf("% x", lease.ServerID))
+	for _, addr := range lease.Addresses {
+		fmt.Println("Address           :", addr.String())
 	}
-
-	messageType = map[byte]string{
-		dhcp.DHCPDiscover: "DHCPDISCOVER",
-		dhcp.DHCPOffer:    "DHCPOFFER",
-		dhcp.DHCPRequest:  "DHCPREQUEST",
-		dhcp.DHCPDecline:  "DHCPDECLINE",
-		dhcp.DHCPAck:      "DHCPACK",
-		dhcp.DHCPNack:     "DHCPNACK",
-		dhcp.DHCPRelease:  "DHCPRELEASE",
+	if len(lease.DNS) > 0 {
+		fmt.Print("Domain Name Server :")
+		for _, ip := range lease.DNS {
+			fmt.Print(" ", ip.String())
+		}
+		fmt.Println()
 	}
+	if len(lease.Domains) > 0 {
+		fmt.Println("Domain Search     :", strings.Join(lease.Domains, " "))
+	}
+	fmt.Println()
 }
 
-func b32(data []byte) uint32 {
-	buf := bytes.NewBuffer(data)
-	var x uint32
-	binary.Read(buf, binary.BigEndian, &x)
-	return x
-}
-
-func ip4(data []byte) string {
-	var ip dhcp.IPv4Address
-	copy(ip[:], data[0:4])
-	return ip.String()
-}
-
-func parseOptions(p *dhcp.Packet) {
-	opts := p.Options
-	fmt.Println("Options:")
-loop:
-	for i := 0; i < len(opts); i++ {
-		o := opts[i]
-
-		switch o {
-		case dhcp.EndOption:
-			fmt.Print("End Option")
-			break loop
-		case dhcp.PadOption:
-			continue
-		}
-
-		length := int(opts[i+1])
-		_, ok := options[o]
-		if ok && options[o].Len >= 0 && options[o].Len != length {
-			fmt.Printf("corrupted option (%d,%d)\n",
-				options[o].Len, length)
-			break loop
-		}
+func discover6(iface string, timeout time.Duration) error {
+	client, err := dhcp6.NewClient(iface)
+	if err != nil {
+		return err
+	}
 
-		if name := options[o].Name; name != "" {
-			fmt.Printf("%24s : ", options[o].Name)
-		} else {
-			fmt.Printf("%24d : ", o)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-		switch o {
-		case dhcp.DHCPMessageType:
-			fmt.Print(messageType[opts[i+2]])
-			break
-		case dhcp.Router, dhcp.DomainNameServer, dhcp.NetBIOSNameServer:
-			// Multiple IP addresses
-			for n := 0; n < length; n += 4 {
-				fmt.Print(ip4(opts[i+2+n:i+6+n]), " ")
-			}
-		case dhcp.ServerIdentifier, dhcp.SubnetMask, dhcp.BroadcastAddress:
-			// Single IP address
-			fmt.Print(ip4(opts[i+2:]))
-			break
-		case dhcp.IPAddressLeaseTime, dhcp.RenewalTimeValue, dhcp.RebindingTimeValue:
-			// 32-bit integer
-			fmt.Print(b32(opts[i+2:]))
-			break
-		case dhcp.HostName, dhcp.DomainName, dhcp.WebProxyServer:
-			// String
-			fmt.Print(string(opts[i+2 : i+2+length]))
-			break
-		case dhcp.DomainSearch:
-			// Compressed domain names (RFC 1035)
-			fmt.Print("[TODO RFC 1035 section 4.1.4]")
-			break
-		case dhcp.VendorSpecific:
-			// Size only
-			fmt.Printf("(%d bytes)", length)
-			break
-		}
-		fmt.Println()
-
-		i += 1 + length
+	fmt.Println("\n>>> Send DHCPv6 solicit")
+	lease, err := client.Discover(ctx)
+	if err != nil {
+		return err
 	}
-}
 
-func showPacket(p *dhcp.Packet) {
-	fmt.Println("Client IP address :", p.Ciaddr.String())
-	fmt.Println("Your IP address   :", p.Yiaddr.String())
-	fmt.Println("Server IP address :", p.Siaddr.String())
-	fmt.Println("Relay IP address  :", p.Giaddr.String())
-	parseOptions(p)
-	fmt.Println()
+	fmt.Println("\n<<< Receive DHCPv6 reply")
+	showLease6(lease)
+	return nil
 }
 
 func checkError(err error) {
@@ -164,9 +83,21 @@ func usage() {
 func main() {
 	var iface string
 	var secs int
+	var v6 bool
+	var raw bool
+	var outFormat string
+	var giaddr string
+	var relayCircuit string
+	var relayRemote string
 
 	flag.StringVar(&iface, "i", "", "network `interface` to use")
 	flag.IntVar(&secs, "t", 5, "timeout in seconds")
+	flag.BoolVar(&v6, "6", false, "run a DHCPv6 solicit instead of a DHCPv4 discover")
+	flag.BoolVar(&raw, "raw", false, "send/receive over a raw link-layer socket instead of UDP (works with no IP assigned)")
+	flag.StringVar(&outFormat, "o", "text", "output `format`: text, json or yaml")
+	flag.StringVar(&giaddr, "giaddr", "", "relay agent `address` to set in the outgoing packet")
+	flag.StringVar(&relayCircuit, "relay-circuit", "", "RFC 3046 option 82 circuit-id to attach")
+	flag.StringVar(&relayRemote, "relay-remote", "", "RFC 3046 option 82 remote-id to attach")
 	flag.Parse()
 
 	if iface == "" {
@@ -174,14 +105,57 @@ func main() {
 		os.Exit(1)
 	}
 
-	mac := ""
+	switch outFormat {
+	case "text", "json", "yaml":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format: %s\n", outFormat)
+		os.Exit(1)
+	}
+
 	timeout := time.Duration(secs) * time.Second
 
+	if v6 {
+		checkError(discover6(iface, timeout))
+		return
+	}
+
+	mac := ""
+
 	mac, err := getMAC(iface)
 	checkError(err)
 
 	fmt.Printf("Interface: %s [%s]\n", iface, mac)
 
+	// Send discover packet
+	p := dhcp.NewDiscoverPacket()
+	p.ParseMAC(mac)
+
+	if giaddr != "" {
+		ip := net.ParseIP(giaddr)
+		if ip == nil || ip.To4() == nil {
+			checkError(fmt.Errorf("%s: not a valid IPv4 address", giaddr))
+		}
+		copy(p.Giaddr[:], ip.To4())
+	}
+	if relayCircuit != "" || relayRemote != "" {
+		var circuitID, remoteID []byte
+		if relayCircuit != "" {
+			circuitID = []byte(relayCircuit)
+		}
+		if relayRemote != "" {
+			remoteID = []byte(relayRemote)
+		}
+		p.AddOption(dhcp.RelayAgentInformation, dhcp.BuildRelayAgentInfo(circuitID, remoteID))
+	}
+
+	fmt.Println("\n>>> Send DHCP discover")
+	showPacket(&p.Packet, outFormat)
+
+	if raw {
+		discoverRaw(iface, p, timeout, outFormat)
+		return
+	}
+
 	// Set up server
 	addr, err := net.ResolveUDPAddr("udp4", ":68")
 	checkError(err)
@@ -189,12 +163,6 @@ func main() {
 	checkError(err)
 	defer conn.Close()
 
-	// Send discover packet
-	p := dhcp.NewDiscoverPacket()
-	p.ParseMAC(mac)
-
-	fmt.Println("\n>>> Send DHCP discover")
-	showPacket(&p.Packet)
 	err = p.Send()
 	checkError(err)
 
@@ -207,7 +175,30 @@ func main() {
 			break
 		}
 		fmt.Println("\n<<< Receive DHCP offer from", remote.IP.String())
-		showPacket(&o)
+		showPacket(&o, outFormat)
+	}
+	fmt.Println("No more offers.")
+}
+
+// discoverRaw runs the same broadcast/collect loop as main's UDP path,
+// but over a raw link-layer socket so it works before the interface
+// has an IPv4 address.
+func discoverRaw(iface string, p *dhcp.DiscoverPacket, timeout time.Duration, outFormat string) {
+	conn, err := dhcp.NewRawConn(iface)
+	checkError(err)
+	defer conn.Close()
+
+	checkError(conn.Send(&p.Packet))
+
+	t := time.Now()
+	for time.Since(t) < timeout {
+		o, err := conn.Receive(timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			break
+		}
+		fmt.Println("\n<<< Receive DHCP offer")
+		showPacket(o, outFormat)
 	}
 	fmt.Println("No more offers.")
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"./dhcp"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// formatText renders a DecodedPacket as the default human-readable
+// output: the four address fields, then the decoded options in a fixed
+// order (one line per address for address-list options), then any
+// option without a dedicated field by code and size only.
+func formatText(dp *dhcp.DecodedPacket) string {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "Client IP address :", ipOrEmpty(dp.ClientIP))
+	fmt.Fprintln(&b, "Your IP address   :", ipOrEmpty(dp.YourIP))
+	fmt.Fprintln(&b, "Server IP address :", ipOrEmpty(dp.ServerIP))
+	fmt.Fprintln(&b, "Relay IP address  :", ipOrEmpty(dp.RelayIP))
+	fmt.Fprintln(&b, "Options:")
+
+	if dp.MessageType != "" {
+		fmt.Fprintf(&b, "%24s : %s\n", "DHCP Message Type", dp.MessageType)
+	}
+	for _, ip := range dp.Routers {
+		fmt.Fprintf(&b, "%24s : %s\n", "Router", ip)
+	}
+	for _, ip := range dp.DomainNameServer {
+		fmt.Fprintf(&b, "%24s : %s\n", "Domain Name Server", ip)
+	}
+	for _, ip := range dp.NetBIOSNameServer {
+		fmt.Fprintf(&b, "%24s : %s\n", "NetBIOS Name Server", ip)
+	}
+	if dp.SubnetMask != nil {
+		fmt.Fprintf(&b, "%24s : %s\n", "Subnet Mask", dp.SubnetMask)
+	}
+	if dp.BroadcastAddress != nil {
+		fmt.Fprintf(&b, "%24s : %s\n", "Broadcast Address", dp.BroadcastAddress)
+	}
+	if dp.ServerIdentifier != nil {
+		fmt.Fprintf(&b, "%24s : %s\n", "Server Identifier", dp.ServerIdentifier)
+	}
+	if dp.LeaseTime != 0 {
+		fmt.Fprintf(&b, "%24s : %d\n", "IP Address Lease Time", int(dp.LeaseTime.Seconds()))
+	}
+	if dp.RenewalTime != 0 {
+		fmt.Fprintf(&b, "%24s : %d\n", "Renewal Time Value", int(dp.RenewalTime.Seconds()))
+	}
+	if dp.RebindingTime != 0 {
+		fmt.Fprintf(&b, "%24s : %d\n", "Rebinding Time Value", int(dp.RebindingTime.Seconds()))
+	}
+	if dp.HostName != "" {
+		fmt.Fprintf(&b, "%24s : %s\n", "Host Name", dp.HostName)
+	}
+	if dp.DomainName != "" {
+		fmt.Fprintf(&b, "%24s : %s\n", "Domain Name", dp.DomainName)
+	}
+	if len(dp.DomainSearch) > 0 {
+		fmt.Fprintf(&b, "%24s : %s\n", "Domain Search", strings.Join(dp.DomainSearch, " "))
+	}
+	if dp.RelayAgentInfo != nil {
+		if dp.RelayAgentInfo.CircuitID != nil {
+			fmt.Fprintf(&b, "%24s : % x\n", "Circuit ID", dp.RelayAgentInfo.CircuitID)
+		}
+		if dp.RelayAgentInfo.RemoteID != nil {
+			fmt.Fprintf(&b, "%24s : % x\n", "Remote ID", dp.RelayAgentInfo.RemoteID)
+		}
+	}
+	for _, o := range dp.Options {
+		fmt.Fprintf(&b, "%24d : (%d bytes)\n", o.Code, len(o.Raw))
+	}
+	fmt.Fprintln(&b, "End Option")
+
+	return b.String()
+}
+
+func formatJSON(dp *dhcp.DecodedPacket) (string, error) {
+	data, err := json.MarshalIndent(dp, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func formatYAML(dp *dhcp.DecodedPacket) (string, error) {
+	var b strings.Builder
+	writeYAMLValue(&b, jsonRoundTrip(dp), 0)
+	return b.String(), nil
+}
+
+// jsonRoundTrip converts dp to plain map[string]interface{}/[]interface{}
+// values via its JSON tags, so the same field set and naming is used by
+// both -o json and -o yaml without a second struct-walking encoder.
+func jsonRoundTrip(dp *dhcp.DecodedPacket) interface{} {
+	data, err := json.Marshal(dp)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	json.Unmarshal(data, &v)
+	return v
+}
+
+// writeYAMLValue is a small hand-rolled YAML emitter covering the
+// scalar/map/slice shapes produced by jsonRoundTrip. It is not a
+// general-purpose YAML encoder: it exists so -o yaml doesn't require an
+// external dependency for this one CLI flag.
+func writeYAMLValue(b *strings.Builder, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(val) {
+			child := val[k]
+			switch child.(type) {
+			case map[string]interface{}, []interface{}:
+				fmt.Fprintf(b, "%s%s:\n", pad, k)
+				writeYAMLValue(b, child, indent+1)
+			default:
+				fmt.Fprintf(b, "%s%s: %s\n", pad, k, yamlScalar(child))
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				fmt.Fprintf(b, "%s-\n", pad)
+				writeYAMLValue(b, item, indent+1)
+			default:
+				fmt.Fprintf(b, "%s- %s\n", pad, yamlScalar(item))
+			}
+		}
+	default:
+		fmt.Fprintf(b, "%s%s\n", pad, yamlScalar(val))
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j-1] > keys[j]; j-- {
+			keys[j-1], keys[j] = keys[j], keys[j-1]
+		}
+	}
+	return keys
+}
+
+func ipOrEmpty(ip net.IP) string {
+	if len(ip) == 0 {
+		return ""
+	}
+	return ip.String()
+}
+
+// formatPacket decodes p and renders it in the requested output
+// format ("text", "json" or "yaml").
+func formatPacket(p *dhcp.Packet, outFormat string) (string, error) {
+	dp, err := dhcp.Decode(p)
+	if err != nil {
+		return "", err
+	}
+
+	switch outFormat {
+	case "json":
+		return formatJSON(dp)
+	case "yaml":
+		return formatYAML(dp)
+	default:
+		return formatText(dp), nil
+	}
+}